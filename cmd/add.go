@@ -19,6 +19,7 @@ import (
 	"github.com/inovacc/cobra-cli/internal/project"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+	"os"
 )
 
 func init() {
@@ -27,8 +28,15 @@ func init() {
 }
 
 var (
-	packageName string
-	parentName  string
+	packageName  string
+	parentName   string
+	registration string
+	cmdFlags     []string
+	cmdRequired  []string
+	cmdArgs      string
+	cmdAliases   []string
+	cmdGroup     string
+	groupTitle   string
 
 	addCmd = &cobra.Command{
 		Use:     "add [command name]",
@@ -41,7 +49,24 @@ and register it to its parent (default rootCmd).
 If you want your command to be public, pass in the command name
 with an initial uppercase letter.
 
-Example: cobra-cli add server -> resulting in a new cmd/server.go`,
+A path adds a nested command, creating any missing command on the way,
+each registered to the one before it. An existing command on the way is
+reused only when it is registered there.
+
+Flags are given as name:type[:default[:usage]], the type being one of
+string, bool, int, int64, float64, duration, stringSlice or intSlice, and
+positional arguments are validated with none, any, valid, exact:N, min:N,
+max:N or range:MIN:MAX.
+
+With --group the command joins a group of its parent, which adds the group
+when it does not have it yet, titled with --group-title.
+
+Example: cobra-cli add server -> resulting in a new cmd/server.go
+         cobra-cli add config/create -> cmd/config.go and cmd/create.go
+         cobra-cli add create -p configCmd -> cmd/create.go under configCmd
+         cobra-cli add deploy --flag env:string:staging:"target env" \
+           --flag replicas:int:3 --required env --args exact:1 --alias dp
+         cobra-cli add serve --group server --group-title "Server Commands"`,
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			var comps []string
 			if len(args) == 0 {
@@ -64,15 +89,74 @@ Example: cobra-cli add server -> resulting in a new cmd/server.go`,
 
 			projectGenerator, err := project.NewProjectGenerator(afs, newProject)
 			cobra.CheckErr(err)
+			cobra.CheckErr(applyConflictPolicy(projectGenerator))
+			projectGenerator.GroupImports = groupImports
+			projectGenerator.Parent = parentName
+			projectGenerator.Registration, err = project.ParseRegistration(registration)
+			cobra.CheckErr(err)
+			projectGenerator.Spec, err = commandSpec()
+			cobra.CheckErr(err)
+			projectGenerator.GroupTitle = groupTitle
+
+			if dryRun || showDiff {
+				cobra.CheckErr(projectGenerator.PrepareCommandModels())
+				if dryRun {
+					cobra.CheckErr(printPlan(os.Stdout, projectGenerator, verbose))
+				} else {
+					cobra.CheckErr(printDiffs(os.Stdout, projectGenerator))
+				}
+				return
+			}
 
 			cobra.CheckErr(projectGenerator.AddCommandProject())
 			fmt.Printf("%s created at %s\n", projectGenerator.CmdName(), projectGenerator.GetProjectPath())
+			cobra.CheckErr(printConflicts(os.Stdout, projectGenerator))
 		},
 	}
 )
 
+// commandSpec returns the flags, args and aliases given to add.
+func commandSpec() (project.CommandSpec, error) {
+	spec := project.CommandSpec{Aliases: cmdAliases, Group: cmdGroup}
+	if groupTitle != "" && cmdGroup == "" {
+		return spec, fmt.Errorf("--group-title needs --group")
+	}
+
+	for _, value := range cmdFlags {
+		flag, err := project.ParseFlag(value)
+		if err != nil {
+			return spec, err
+		}
+		spec.Flags = append(spec.Flags, flag)
+	}
+
+	if err := spec.RequireFlags(cmdRequired); err != nil {
+		return spec, err
+	}
+
+	if cmdArgs != "" {
+		var err error
+		if spec.Args, err = project.ParseArgs(cmdArgs); err != nil {
+			return spec, err
+		}
+	}
+	return spec, spec.Validate()
+}
+
 func init() {
 	addCmd.Flags().StringVarP(&packageName, "package", "t", "", "target package name (e.g. github.com/spf13/hugo)")
 	addCmd.Flags().StringVarP(&parentName, "parent", "p", "rootCmd", "variable name of parent command for this command")
+	addCmd.Flags().StringVar(&registration, "register", string(project.RegisterInit), "where to register the command: init (an init() in its own file) or parent (inserted into the init() of its parent)")
+	addCmd.Flags().StringArrayVar(&cmdFlags, "flag", nil, "flag of the command as name:type[:default[:usage]], may be repeated")
+	addCmd.Flags().StringSliceVar(&cmdRequired, "required", nil, "names of the flags to mark as required")
+	addCmd.Flags().StringVar(&cmdArgs, "args", "", "validation of positional arguments: none, any, valid, exact:N, min:N, max:N or range:MIN:MAX")
+	addCmd.Flags().StringSliceVar(&cmdAliases, "alias", nil, "alias of the command, may be repeated")
+	addCmd.Flags().StringVar(&cmdGroup, "group", "", "ID of the group of the parent the command belongs to, added to the parent when missing")
+	addCmd.Flags().StringVar(&groupTitle, "group-title", "", "title of the group when it is added to the parent (default \"<Group> Commands\")")
+	addCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the files that would be generated without writing them")
+	addCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "with --dry-run, also print the rendered content of every file")
+	addCmd.Flags().BoolVar(&showDiff, "diff", false, "show a unified diff between existing files and the generated ones, failing if any differ")
+	addCmd.Flags().BoolVar(&groupImports, "group-imports", false, "group imports of generated Go files goimports-style: standard library, third party, then the module")
+	addCmd.Flags().StringVar(&onConflict, "on-conflict", string(project.ConflictFail), "what to do with files that already exist: fail, skip, overwrite or new")
 	cobra.CheckErr(addCmd.Flags().MarkDeprecated("package", "this operation has been removed."))
 }
@@ -0,0 +1,70 @@
+// Copyright © 2021 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/inovacc/cobra-cli/internal/project"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+var (
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration of cobra-cli",
+	}
+
+	configShowCmd = &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration and where each value comes from",
+		Long: `Show (cobra-cli config show) prints the config file read, then the value of
+every setting along with where it comes from: a flag, a COBRA_ environment
+variable, the config file or the default.
+
+The config file is the one given with --config or else the first found of:
+  - .cobra.yaml in the working directory or a parent, up to the module root
+  - $XDG_CONFIG_HOME/cobra-cli/config.yaml (default ~/.config)
+  - ~/.cobra.yaml`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			cobra.CheckErr(printConfig(os.Stdout, project.EffectiveConfig(cmd.Flags(), loadedConfig)))
+		},
+	}
+)
+
+// printConfig writes the config file read and values to w.
+func printConfig(w io.Writer, values []project.ConfigValue) error {
+	file := loadedConfig
+	if file == "" {
+		file = "none"
+	}
+	if _, err := fmt.Fprintf(w, "config file: %s\n\n", file); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, value := range values {
+		if _, err := fmt.Fprintf(tw, "%s\t%q\t%s\n", value.Key, value.Value, value.Source); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+}
@@ -0,0 +1,103 @@
+// Copyright © 2021 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/inovacc/cobra-cli/internal/project"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var (
+	specPath string
+
+	generateCmd = &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a command tree from a YAML or JSON spec",
+		Long: `Generate (cobra-cli generate) creates every command declared in a spec file
+and brings the commands it already generated up to date with it.
+
+The spec lists the commands with their parent, help texts, flags, positional
+argument validation, aliases and groups:
+
+  groups:
+    - id: manage
+      title: Management Commands
+  commands:
+    - name: config
+      group: manage
+      short: Manage the configuration
+    - name: set
+      parent: config
+      args: exact:2
+      flags:
+        - name: global
+          type: bool
+          usage: write the global configuration
+
+Running generate again after editing the spec updates the fields, flags and
+groups of existing commands; the bodies of their Run functions are left
+untouched. A field or flag omitted from the spec is left as it is: short,
+long, args, aliases, hidden and the others are only set when the spec has
+them, and nothing is ever removed.`,
+		Args: cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			afs := afero.NewOsFs()
+			wd, err := os.Getwd()
+			cobra.CheckErr(err)
+
+			spec, err := project.LoadSpec(afs, specPath)
+			cobra.CheckErr(err)
+
+			newProject, err := project.NewProject(nil)
+			cobra.CheckErr(err)
+
+			projectGenerator, err := project.NewProjectGenerator(afs, newProject)
+			cobra.CheckErr(err)
+			cobra.CheckErr(applyConflictPolicy(projectGenerator))
+			projectGenerator.GroupImports = groupImports
+			projectGenerator.Registration, err = project.ParseRegistration(registration)
+			cobra.CheckErr(err)
+
+			if dryRun {
+				cobra.CheckErr(projectGenerator.PrepareSpecModels(spec, wd))
+				cobra.CheckErr(printPlan(os.Stdout, projectGenerator, verbose))
+				return
+			}
+
+			cobra.CheckErr(projectGenerator.GenerateFromSpec(spec, wd))
+			for _, content := range projectGenerator.Content {
+				if !content.Dirty {
+					fmt.Printf("created %s\n", content.FilePath)
+				}
+			}
+			for _, filePath := range projectGenerator.Updated() {
+				fmt.Printf("updated %s\n", filePath)
+			}
+			cobra.CheckErr(printConflicts(os.Stdout, projectGenerator))
+		},
+	}
+)
+
+func init() {
+	generateCmd.Flags().StringVar(&specPath, "spec", "", "YAML or JSON file declaring the command tree")
+	generateCmd.Flags().StringVar(&registration, "register", string(project.RegisterInit), "where to register new commands: init (an init() in its own file) or parent (inserted into the init() of its parent)")
+	generateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the files that would be generated or updated without writing them")
+	generateCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "with --dry-run, also print the rendered content of every file")
+	generateCmd.Flags().BoolVar(&groupImports, "group-imports", false, "group imports of generated Go files goimports-style: standard library, third party, then the module")
+	generateCmd.Flags().StringVar(&onConflict, "on-conflict", string(project.ConflictFail), "what to do with files that already exist: fail, skip, overwrite or new")
+	cobra.CheckErr(generateCmd.MarkFlagRequired("spec"))
+}
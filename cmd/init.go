@@ -19,7 +19,7 @@ import (
 	"github.com/inovacc/cobra-cli/internal/project"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
-	"os/exec"
+	"os"
 )
 
 func init() {
@@ -28,6 +28,9 @@ func init() {
 }
 
 var (
+	packSource string
+	packVars   map[string]string
+
 	initCmd = &cobra.Command{
 		Use:     "init [path]",
 		Aliases: []string{"initialize", "initialise", "create"},
@@ -36,6 +39,9 @@ var (
 and the appropriate structure for a Cobra-based CLI application.
 
 Cobra init must be run inside of a go module (please run "go mod init <MODNAME>" first)
+
+The files generated come from a template pack, the built-in one unless
+--pack names a directory or .tar.gz archive holding a pack.yaml manifest.
 `,
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			var comps []string
@@ -59,24 +65,44 @@ Cobra init must be run inside of a go module (please run "go mod init <MODNAME>"
 
 			projectGenerator, err := project.NewProjectGenerator(afs, newProject)
 			cobra.CheckErr(err)
+			cobra.CheckErr(applyConflictPolicy(projectGenerator))
+			projectGenerator.GroupImports = groupImports
+
+			if packSource != "" && packSource != project.DefaultPack {
+				pack, err := project.LoadPack(afs, packSource)
+				cobra.CheckErr(err)
+				projectGenerator.Pack = pack
+			}
+			newProject.Vars = packVars
 
 			cobra.CheckErr(projectGenerator.PrepareModels())
-			cobra.CheckErr(projectGenerator.CreateProject())
 
-			cobra.CheckErr(project.GoGet("github.com/spf13/cobra"))
-			cobra.CheckErr(project.GoGet("github.com/google/uuid"))
-			cobra.CheckErr(project.GoGet("github.com/inovacc/logger"))
-			cobra.CheckErr(project.GoGet("github.com/spf13/afero"))
-			cobra.CheckErr(project.GoGet("github.com/spf13/viper"))
-			cobra.CheckErr(project.GoGet("gopkg.in/yaml.v3"))
-			cobra.CheckErr(project.GoGet("go.uber.org/automaxprocs"))
+			if dryRun {
+				cobra.CheckErr(printPlan(os.Stdout, projectGenerator, verbose))
+				return
+			}
 
-			cmd := exec.Command("go", "mod", "tidy")
-			cmd.Stdout = nil
-			cmd.Stderr = nil
-			cobra.CheckErr(cmd.Run())
+			if showDiff {
+				cobra.CheckErr(printDiffs(os.Stdout, projectGenerator))
+				return
+			}
 
+			cobra.CheckErr(projectGenerator.CreateProject())
+
+			cobra.CheckErr(projectGenerator.InstallDependencies())
+
+			cobra.CheckErr(printConflicts(os.Stdout, projectGenerator))
 			fmt.Printf("Your Cobra application is ready at\n%s\n", projectGenerator.GetProjectPath())
 		},
 	}
 )
+
+func init() {
+	initCmd.Flags().StringVar(&packSource, "pack", project.DefaultPack, "template pack to scaffold from: a directory or .tar.gz archive with a pack.yaml manifest")
+	initCmd.Flags().StringToStringVar(&packVars, "var", nil, "variable for the templates of the pack, as name=value")
+	initCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the files that would be generated without writing them")
+	initCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "with --dry-run, also print the rendered content of every file")
+	initCmd.Flags().BoolVar(&showDiff, "diff", false, "show a unified diff between existing files and the generated ones, failing if any differ")
+	initCmd.Flags().BoolVar(&groupImports, "group-imports", false, "group imports of generated Go files goimports-style: standard library, third party, then the module")
+	initCmd.Flags().StringVar(&onConflict, "on-conflict", string(project.ConflictFail), "what to do with files that already exist: fail, skip, overwrite or new")
+}
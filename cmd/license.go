@@ -0,0 +1,183 @@
+// Copyright © 2021 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/inovacc/cobra-cli/internal/project"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"time"
+)
+
+var (
+	copyrightYear string
+
+	licenseCmd = &cobra.Command{
+		Use:   "license",
+		Short: "Manage the license headers of the Go files of a project",
+		Long: `License (cobra-cli license) manages the license header of every Go file of
+the module holding the working directory: the /* */ comment before the
+package clause, with the copyright line and the header of the license.
+
+The license is the one recorded in the manifest of the project, or else the
+one given with --license or the config, or else the one identified from the
+LICENSE file, as license detect does. Generated files, vendor and testdata
+directories and nested modules are skipped.`,
+	}
+
+	licenseApplyCmd = &cobra.Command{
+		Use:   "apply",
+		Short: "Add the license header to the Go files missing it",
+		Long: `Apply (cobra-cli license apply) adds the license header of the project to
+every Go file that has none. Files with another header, // comments
+included, are listed and left as is.`,
+		Args: cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			projectGenerator, wd := refactoringGenerator()
+
+			results, err := projectGenerator.CheckHeaders(wd)
+			cobra.CheckErr(err)
+
+			if !dryRun {
+				cobra.CheckErr(projectGenerator.WriteHeaders(results))
+			}
+			cobra.CheckErr(printHeaders(os.Stdout, results, dryRun))
+		},
+	}
+
+	licenseCheckCmd = &cobra.Command{
+		Use:   "check",
+		Short: "List the Go files with a missing or mismatching license header",
+		Long: `Check (cobra-cli license check) lists the Go files without the license header
+of the project, or with another one, and exits with status 1 when there is
+any.`,
+		Args: cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			projectGenerator, wd := refactoringGenerator()
+
+			results, err := projectGenerator.CheckHeaders(wd)
+			cobra.CheckErr(err)
+
+			var problems int
+			for _, result := range results {
+				if result.Status == project.HeaderOK {
+					continue
+				}
+				problems++
+				cobra.CheckErr(printHeader(os.Stdout, result))
+			}
+			if problems > 0 {
+				cobra.CheckErr(fmt.Errorf("%d file(s) without the license header", problems))
+			}
+		},
+	}
+
+	licenseDetectCmd = &cobra.Command{
+		Use:   "detect",
+		Short: "Identify the license of the project from its LICENSE file",
+		Long: `Detect (cobra-cli license detect) compares the LICENSE file of the project
+holding the working directory with the licenses cobra-cli knows, ignoring
+copyright lines, case and layout, and prints the SPDX identifier of the
+closest one with a confidence score from 0 to 1.
+
+It exits with status 1 when the file is not close enough to any of them. New
+commands of a project whose root.go has no license header get the header of
+the license detected.`,
+		Args: cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			projectGenerator, wd := refactoringGenerator()
+
+			match, err := projectGenerator.DetectLicense(wd)
+			cobra.CheckErr(err)
+
+			if !match.Confident() {
+				if match.License == nil {
+					cobra.CheckErr(fmt.Errorf("%s matches no known license", match.File))
+				}
+				cobra.CheckErr(fmt.Errorf("%s matches no known license, the closest is %s with a confidence of %.2f", match.File, match.License.SPDX, match.Confidence))
+			}
+			fmt.Printf("%s %.2f\n", match.License.SPDX, match.Confidence)
+		},
+	}
+
+	licenseUpdateCmd = &cobra.Command{
+		Use:   "update",
+		Short: "Extend the copyright years of the license headers",
+		Long: `Update (cobra-cli license update) extends the copyright years of the license
+headers and of the LICENSE file up to --year, the current year by default:
+"Copyright © 2021" becomes "Copyright © 2021-2026" and a range such as
+2021-2024 becomes 2021-2026.`,
+		Args: cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			projectGenerator, wd := refactoringGenerator()
+
+			results, err := projectGenerator.UpdateCopyright(wd, copyrightYear)
+			cobra.CheckErr(err)
+
+			if !dryRun {
+				cobra.CheckErr(projectGenerator.WriteHeaders(results))
+			}
+			cobra.CheckErr(printHeaders(os.Stdout, results, dryRun))
+		},
+	}
+)
+
+// printHeaders writes the status of every file changed, or left with
+// another header, to w.
+func printHeaders(w io.Writer, results []project.HeaderResult, dryRun bool) error {
+	var changed int
+	for _, result := range results {
+		if result.Status == project.HeaderOK {
+			continue
+		}
+		if result.Body != nil {
+			changed++
+		}
+		if result.Status == project.HeaderMissing {
+			result.Status = "added"
+		}
+		if err := printHeader(w, result); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		_, err := fmt.Fprintf(w, "dry run: %d file(s), nothing written\n", changed)
+		return err
+	}
+	return nil
+}
+
+// printHeader writes the status of result to w.
+func printHeader(w io.Writer, result project.HeaderResult) error {
+	line := fmt.Sprintf("%-10s %s", result.Status, result.FilePath)
+	if result.Reason != "" {
+		line += " (" + result.Reason + ")"
+	}
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+func init() {
+	licenseApplyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the files that would be changed without writing them")
+	licenseUpdateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the files that would be changed without writing them")
+	licenseUpdateCmd.Flags().StringVar(&copyrightYear, "year", time.Now().Format("2006"), "year the copyright years are extended to")
+
+	licenseCmd.AddCommand(licenseApplyCmd)
+	licenseCmd.AddCommand(licenseCheckCmd)
+	licenseCmd.AddCommand(licenseDetectCmd)
+	licenseCmd.AddCommand(licenseUpdateCmd)
+}
@@ -0,0 +1,74 @@
+// Copyright © 2021 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/inovacc/cobra-cli/internal/project"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+var (
+	licensesCmd = &cobra.Command{
+		Use:   "licenses",
+		Short: "Inspect the licenses cobra-cli can generate",
+	}
+
+	licensesListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the supported licenses and their SPDX identifiers",
+		Long: `List (cobra-cli licenses list) prints the licenses --license accepts: their
+SPDX identifier, the short name cobra-cli knows them by and their full name.
+
+--license accepts any of these names, ignoring case, spaces and punctuation,
+along with common aliases such as "apache 2.0" or "gplv3". Any other SPDX
+identifier is accepted too, with no LICENSE written and an
+SPDX-License-Identifier header in the generated files.`,
+		Args: cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			licenses, err := project.Licenses(afero.NewOsFs())
+			cobra.CheckErr(err)
+			cobra.CheckErr(printLicenses(os.Stdout, licenses))
+		},
+	}
+)
+
+// printLicenses writes licenses to w, sorted by SPDX identifier.
+func printLicenses(w io.Writer, licenses map[string]*project.License) error {
+	keys := slices.SortedFunc(maps.Keys(licenses), func(a, b string) int {
+		return strings.Compare(strings.ToLower(licenses[a].SPDX), strings.ToLower(licenses[b].SPDX))
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "SPDX\tNAME\tDESCRIPTION"); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", licenses[key].SPDX, key, licenses[key].Name); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func init() {
+	licensesCmd.AddCommand(licensesListCmd)
+}
@@ -0,0 +1,172 @@
+// Copyright © 2021 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/inovacc/cobra-cli/internal/project"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+)
+
+var (
+	lintFormat string
+
+	lintCmd = &cobra.Command{
+		Use:   "lint",
+		Short: "Check the commands of a Cobra Application for common mistakes",
+		Long: `Lint (cobra-cli lint) analyzes the command tree of the application holding
+the working directory, as cobra-cli tree does, and reports:
+
+  - children of a command sharing a name or an alias
+  - Use values not starting with the name of the command
+  - flags declared twice, or shadowing a persistent flag of an ancestor
+  - flags sharing a shorthand letter
+  - commands without a Short description
+  - commands setting both Run and RunE, or both variants of another hook
+  - commands declared but never registered
+
+Every problem is reported as file:line. With --format json or sarif the
+report is printed for CI tools instead. Lint exits with status 1 when it
+finds a problem.`,
+		Args: cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			printLint, ok := lintPrinters[lintFormat]
+			if !ok {
+				cobra.CheckErr(fmt.Errorf("unknown format %q, want text, json or sarif", lintFormat))
+			}
+
+			projectGenerator, wd := refactoringGenerator()
+
+			tree, err := projectGenerator.CommandTree(wd)
+			cobra.CheckErr(err)
+
+			diagnostics := tree.Lint()
+			cobra.CheckErr(printLint(os.Stdout, diagnostics))
+			if len(diagnostics) > 0 {
+				cobra.CheckErr(fmt.Errorf("%d problem(s) found", len(diagnostics)))
+			}
+		},
+	}
+)
+
+// lintPrinters write the diagnostics of lint in each --format.
+var lintPrinters = map[string]func(io.Writer, []project.Diagnostic) error{
+	"text":  printDiagnostics,
+	"json":  printDiagnosticsJSON,
+	"sarif": printSARIF,
+}
+
+// printDiagnostics writes one diagnostic per line to w.
+func printDiagnostics(w io.Writer, diagnostics []project.Diagnostic) error {
+	for _, diagnostic := range diagnostics {
+		if _, err := fmt.Fprintln(w, diagnostic); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printDiagnosticsJSON(w io.Writer, diagnostics []project.Diagnostic) error {
+	if diagnostics == nil {
+		diagnostics = []project.Diagnostic{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(diagnostics)
+}
+
+// sarifLog is the subset of a SARIF 2.1.0 log printed by lint.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver struct {
+			Name           string      `json:"name"`
+			Version        string      `json:"version"`
+			InformationURI string      `json:"informationUri"`
+			Rules          []sarifRule `json:"rules"`
+		} `json:"driver"`
+	} `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region struct {
+			StartLine int `json:"startLine"`
+		} `json:"region"`
+	} `json:"physicalLocation"`
+}
+
+// printSARIF writes diagnostics to w as a SARIF log, the format code
+// scanning tools such as GitHub's read.
+func printSARIF(w io.Writer, diagnostics []project.Diagnostic) error {
+	run := sarifRun{Results: []sarifResult{}}
+	run.Tool.Driver.Name = "cobra-cli"
+	run.Tool.Driver.Version = project.Version()
+	run.Tool.Driver.InformationURI = "https://github.com/inovacc/cobra-cli"
+	for _, rule := range project.LintRules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: rule.ID, ShortDescription: sarifMessage{Text: rule.Description}})
+	}
+
+	for _, diagnostic := range diagnostics {
+		var location sarifLocation
+		location.PhysicalLocation.ArtifactLocation.URI = diagnostic.File
+		location.PhysicalLocation.Region.StartLine = diagnostic.Line
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    diagnostic.Rule,
+			Level:     string(diagnostic.Severity),
+			Message:   sarifMessage{Text: diagnostic.Message},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+func init() {
+	lintCmd.Flags().StringVar(&lintFormat, "format", "text", "output format: text, json or sarif")
+}
@@ -0,0 +1,60 @@
+// Copyright © 2021 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var (
+	moveTo      string
+	movePackage string
+
+	moveCmd = &cobra.Command{
+		Use:   "move [command name] --to [parent]",
+		Short: "Move a command under a different parent",
+		Long: `Move (cobra-cli move) registers a command to a different parent by rewriting
+the AddCommand call registering it. Moving a command under itself or one of
+its descendants is refused. The group of the command is added to the new
+parent when it does not add it yet.
+
+With --package the file of the command moves into a sub-package of cmd: its
+variable is exported and the files of package cmd referring to it, tests
+included, import the sub-package.
+
+Example: cobra-cli move user --to admin -> adminCmd.AddCommand(userCmd)
+         cobra-cli move user --to admin --package admin
+           -> cmd/admin/user.go, adminCmd.AddCommand(admin.UserCmd)`,
+		Args: cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			projectGenerator, wd := refactoringGenerator()
+
+			refactoring, err := projectGenerator.PlanMove(wd, args[0], moveTo, movePackage)
+			cobra.CheckErr(err)
+
+			if !dryRun {
+				cobra.CheckErr(projectGenerator.Refactor(refactoring))
+			}
+			cobra.CheckErr(printRefactoring(os.Stdout, refactoring, dryRun))
+		},
+	}
+)
+
+func init() {
+	moveCmd.Flags().StringVar(&moveTo, "to", "", "name or variable of the new parent command")
+	moveCmd.Flags().StringVar(&movePackage, "package", "", "sub-package of cmd to move the file of the command into")
+	moveCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the files that would be changed without writing them")
+	cobra.CheckErr(moveCmd.MarkFlagRequired("to"))
+}
@@ -0,0 +1,100 @@
+// Copyright © 2021 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/inovacc/cobra-cli/internal/project"
+	"io"
+)
+
+var (
+	dryRun       bool
+	verbose      bool
+	showDiff     bool
+	groupImports bool
+	onConflict   string
+)
+
+// applyConflictPolicy sets the --on-conflict policy on projectGenerator.
+func applyConflictPolicy(projectGenerator *project.Generator) error {
+	policy, err := project.ParseConflictPolicy(onConflict)
+	if err != nil {
+		return err
+	}
+	projectGenerator.OnConflict = policy
+	return nil
+}
+
+// printConflicts writes a summary of every conflict found while generating.
+func printConflicts(w io.Writer, projectGenerator *project.Generator) error {
+	if len(projectGenerator.Conflicts) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(w, "%d conflict(s):\n", len(projectGenerator.Conflicts)); err != nil {
+		return err
+	}
+
+	for _, conflict := range projectGenerator.Conflicts {
+		if _, err := fmt.Fprintf(w, "  %s\n", conflict); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printPlan writes the generation plan of projectGenerator to w. With
+// verbose set, the rendered body of every file follows its plan line.
+func printPlan(w io.Writer, projectGenerator *project.Generator, verbose bool) error {
+	entries, err := projectGenerator.Plan()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if _, err := fmt.Fprintf(w, "%-9s %s (%d bytes)\n", entry.Action, entry.FilePath, entry.Size); err != nil {
+			return err
+		}
+
+		if verbose {
+			if _, err := fmt.Fprintf(w, "%s\n", entry.Body); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "dry run: %d file(s), nothing written\n", len(entries))
+	return err
+}
+
+// printDiffs writes a unified diff for every existing file that differs
+// from its freshly rendered content, and fails when there is at least one.
+func printDiffs(w io.Writer, projectGenerator *project.Generator) error {
+	diffs, err := projectGenerator.Diff()
+	if err != nil {
+		return err
+	}
+
+	for _, diff := range diffs {
+		if _, err := io.WriteString(w, diff.Diff); err != nil {
+			return err
+		}
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("%d generated file(s) differ from the files on disk", len(diffs))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright © 2021 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/inovacc/cobra-cli/internal/project"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+)
+
+var (
+	recursive bool
+
+	removeCmd = &cobra.Command{
+		Use:     "remove [command name]",
+		Aliases: []string{"rm"},
+		Short:   "Remove a command from a Cobra Application",
+		Long: `Remove (cobra-cli remove) deletes the file declaring a command and the
+AddCommand call registering it to its parent.
+
+A command with children is only removed with --recursive, which removes all
+of its descendants too. Nothing is removed when the rest of the package still
+uses the command or the variables declared next to it.
+
+Example: cobra-cli remove serve -> removes cmd/serve.go
+         cobra-cli remove config --recursive -> removes config and its children`,
+		Args: cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			projectGenerator, wd := refactoringGenerator()
+
+			refactoring, err := projectGenerator.PlanRemove(wd, args[0], recursive)
+			cobra.CheckErr(err)
+
+			if !dryRun {
+				cobra.CheckErr(projectGenerator.Refactor(refactoring))
+			}
+			cobra.CheckErr(printRefactoring(os.Stdout, refactoring, dryRun))
+		},
+	}
+)
+
+// refactoringGenerator returns a generator for the project holding the
+// working directory, and the working directory.
+func refactoringGenerator() (*project.Generator, string) {
+	wd, err := os.Getwd()
+	cobra.CheckErr(err)
+
+	newProject, err := project.NewProject(nil)
+	cobra.CheckErr(err)
+
+	projectGenerator, err := project.NewProjectGenerator(afero.NewOsFs(), newProject)
+	cobra.CheckErr(err)
+	return projectGenerator, wd
+}
+
+// printRefactoring writes every change of refactoring to w.
+func printRefactoring(w io.Writer, refactoring *project.Refactoring, dryRun bool) error {
+	for _, change := range refactoring.Changes {
+		if _, err := fmt.Fprintf(w, "%-7s %s\n", change.Action, change.FilePath); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		_, err := fmt.Fprintf(w, "dry run: %d file(s), nothing written\n", len(refactoring.Changes))
+		return err
+	}
+	return nil
+}
+
+func init() {
+	removeCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "remove the children of the command too")
+	removeCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the files that would be changed without writing them")
+}
@@ -0,0 +1,47 @@
+// Copyright © 2021 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename [old name] [new name]",
+	Short: "Rename a command of a Cobra Application",
+	Long: `Rename (cobra-cli rename) renames the variable of a command and every
+reference to it across the cmd package, tests included, found by
+type-checking the package. The first word of its Use is replaced too, and its
+file is renamed when it is named after the command.
+
+Example: cobra-cli rename serve run -> cmd/serve.go becomes cmd/run.go,
+                                      serveCmd becomes runCmd`,
+	Args: cobra.ExactArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		projectGenerator, wd := refactoringGenerator()
+
+		refactoring, err := projectGenerator.PlanRename(wd, args[0], args[1])
+		cobra.CheckErr(err)
+
+		if !dryRun {
+			cobra.CheckErr(projectGenerator.Refactor(refactoring))
+		}
+		cobra.CheckErr(printRefactoring(os.Stdout, refactoring, dryRun))
+	},
+}
+
+func init() {
+	renameCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the files that would be changed without writing them")
+}
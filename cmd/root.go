@@ -14,11 +14,18 @@
 package cmd
 
 import (
+	"github.com/inovacc/cobra-cli/internal/project"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"os"
 )
 
 var (
+	// cfgFile is the --config flag, loadedConfig the config file read
+	cfgFile      string
+	loadedConfig string
+
 	rootCmd = &cobra.Command{
 		Use:   "cobra-cli",
 		Short: "A generator for Cobra based Applications",
@@ -33,17 +40,41 @@ func Execute() error {
 }
 
 func init() {
+	cobra.OnInitialize(initConfig)
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.Version = project.Version()
 
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is the first of ./.cobra.yaml up to the module root, $XDG_CONFIG_HOME/cobra-cli/config.yaml and ~/.cobra.yaml)")
 	rootCmd.PersistentFlags().StringP("author", "a", "YOUR NAME", "author name for copyright attribution")
 	rootCmd.PersistentFlags().StringP("license", "l", "none", "name of license for the project")
+	rootCmd.PersistentFlags().String("templates", "", "directory of templates overriding the built-in ones with the same file name")
 
 	cobra.CheckErr(viper.BindPFlag("author", rootCmd.PersistentFlags().Lookup("author")))
 	cobra.CheckErr(viper.BindPFlag("license", rootCmd.PersistentFlags().Lookup("license")))
+	cobra.CheckErr(viper.BindPFlag("templates", rootCmd.PersistentFlags().Lookup("templates")))
 
 	viper.SetDefault("author", "NAME HERE <EMAIL ADDRESS>")
 	viper.SetDefault("license", "none")
 
 	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(generateCmd)
+	rootCmd.AddCommand(licenseCmd)
+	rootCmd.AddCommand(licensesCmd)
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(removeCmd)
+	rootCmd.AddCommand(moveCmd)
+	rootCmd.AddCommand(renameCmd)
+	rootCmd.AddCommand(treeCmd)
+	rootCmd.AddCommand(upgradeCmd)
+}
+
+// initConfig reads the config file and the COBRA_ environment variables.
+func initConfig() {
+	wd, err := os.Getwd()
+	cobra.CheckErr(err)
+
+	loadedConfig, err = project.LoadConfig(afero.NewOsFs(), cfgFile, wd)
+	cobra.CheckErr(err)
 }
@@ -0,0 +1,156 @@
+// Copyright © 2021 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/inovacc/cobra-cli/internal/project"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	treeJSON bool
+
+	treeCmd = &cobra.Command{
+		Use:   "tree",
+		Short: "Print the command tree of a Cobra Application",
+		Long: `Tree (cobra-cli tree) prints the commands of the application holding the
+working directory, as registered with AddCommand, along with their short
+description and aliases. The cmd package and its sub-packages are analyzed
+statically, nothing of the application is built or run.
+
+With --json the tree is printed as JSON, each command with its file, help,
+aliases, groups, flags and children.`,
+		Args: cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			projectGenerator, wd := refactoringGenerator()
+
+			tree, err := projectGenerator.CommandTree(wd)
+			cobra.CheckErr(err)
+
+			if treeJSON {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				cobra.CheckErr(encoder.Encode(tree))
+				return
+			}
+			cobra.CheckErr(printTree(os.Stdout, tree))
+		},
+	}
+)
+
+// printTree writes the commands of tree to w, one per line below their
+// parent, then the commands never registered. The children of a command
+// adding groups are listed under the title of their group.
+func printTree(w io.Writer, tree *project.CommandTree) error {
+	var lines []treeLine
+	if tree.Root != nil {
+		lines = append(lines, commandLine(tree.Root))
+	}
+	if len(tree.Orphans) > 0 {
+		lines = append(lines, treeLine{text: "\nnot registered:"})
+		for _, orphan := range tree.Orphans {
+			lines = append(lines, commandLine(orphan))
+		}
+	}
+
+	for _, line := range lines {
+		if err := line.write(w, "", "", ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// treeLine is a line of printTree, a command or the title of a group, with
+// the lines below it.
+type treeLine struct {
+	text     string
+	children []treeLine
+}
+
+// commandLine returns the line of node and, below it, its children.
+func commandLine(node *project.CommandNode) treeLine {
+	line := treeLine{text: describeCommand(node)}
+
+	groups := node.ChildGroups()
+	if len(groups) == 1 && groups[0].ID == "" {
+		for _, child := range groups[0].Children {
+			line.children = append(line.children, commandLine(child))
+		}
+		return line
+	}
+
+	for _, group := range groups {
+		title := group.Title
+		switch {
+		case group.ID == "":
+			title = "Additional Commands"
+		case title == "":
+			title = group.ID + " (group not added)"
+		}
+
+		heading := treeLine{text: title + ":"}
+		for _, child := range group.Children {
+			heading.children = append(heading.children, commandLine(child))
+		}
+		line.children = append(line.children, heading)
+	}
+	return line
+}
+
+func (line treeLine) write(w io.Writer, prefix, branch, indent string) error {
+	if _, err := fmt.Fprintf(w, "%s%s%s\n", prefix, branch, line.text); err != nil {
+		return err
+	}
+	for i, child := range line.children {
+		if i == len(line.children)-1 {
+			if err := child.write(w, prefix+indent, "└── ", "    "); err != nil {
+				return err
+			}
+		} else if err := child.write(w, prefix+indent, "├── ", "│   "); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describeCommand returns the line printed for node.
+func describeCommand(node *project.CommandNode) string {
+	line := node.Name
+	if line == "" {
+		line = node.Var
+	}
+	if len(node.Aliases) > 0 {
+		line += " (" + strings.Join(node.Aliases, ", ") + ")"
+	}
+	if node.Short != "" {
+		line += " - " + node.Short
+	}
+	if node.Hidden {
+		line += " [hidden]"
+	}
+	if node.Deprecated != "" {
+		line += " [deprecated]"
+	}
+	return line
+}
+
+func init() {
+	treeCmd.Flags().BoolVar(&treeJSON, "json", false, "print the tree as JSON")
+}
@@ -0,0 +1,99 @@
+// Copyright © 2021 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/inovacc/cobra-cli/internal/project"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Merge changes of the templates into a generated project",
+	Long: `Upgrade (cobra-cli upgrade) brings the files of a project generated with a
+manifest up to date with the current templates.
+
+The original template of every generated file and its current template are
+both rendered with the inputs recorded in .cobra-cli.yaml, and the change
+between the two is merged into the file as you left it. Files you did not
+edit are replaced, clean merges are applied, and where your edits clash with
+the templates the file is written with conflict markers to resolve by hand.`,
+	Args: cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		afs := afero.NewOsFs()
+		wd, err := os.Getwd()
+		cobra.CheckErr(err)
+
+		newProject, err := project.NewProject(nil)
+		cobra.CheckErr(err)
+
+		projectGenerator, err := project.NewProjectGenerator(afs, newProject)
+		cobra.CheckErr(err)
+		projectGenerator.GroupImports = groupImports
+
+		if packSource != "" && packSource != project.DefaultPack {
+			pack, err := project.LoadPack(afs, packSource)
+			cobra.CheckErr(err)
+			projectGenerator.Pack = pack
+		}
+
+		results, err := projectGenerator.PlanUpgrade(wd)
+		cobra.CheckErr(err)
+
+		if !dryRun {
+			cobra.CheckErr(projectGenerator.Upgrade(results))
+		}
+		cobra.CheckErr(printUpgrade(os.Stdout, results, dryRun))
+	},
+}
+
+// printUpgrade writes the status of every upgraded file to w and fails when
+// files were left with conflicts to resolve.
+func printUpgrade(w io.Writer, results []project.UpgradeResult, dryRun bool) error {
+	var conflicts int
+	for _, result := range results {
+		line := fmt.Sprintf("%-10s %s", result.Status, result.FilePath)
+		switch {
+		case result.Reason != "":
+			line += " (" + result.Reason + ")"
+		case result.Conflicts > 0:
+			line += fmt.Sprintf(" (%d conflict(s))", result.Conflicts)
+			conflicts++
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		_, err := fmt.Fprintf(w, "dry run: %d file(s), nothing written\n", len(results))
+		return err
+	}
+
+	if conflicts > 0 {
+		return fmt.Errorf("%d file(s) have conflicts, resolve the conflict markers and commit the result", conflicts)
+	}
+	return nil
+}
+
+func init() {
+	upgradeCmd.Flags().StringVar(&packSource, "pack", project.DefaultPack, "template pack the project was generated from: a directory or .tar.gz archive with a pack.yaml manifest")
+	upgradeCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print what would be upgraded without writing anything")
+	upgradeCmd.Flags().BoolVar(&groupImports, "group-imports", false, "group imports of generated Go files goimports-style: standard library, third party, then the module")
+}
@@ -0,0 +1,280 @@
+package project
+
+import (
+	"fmt"
+	"github.com/spf13/afero"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// commandPackage is the parsed source of the cmd package of a project.
+type commandPackage struct {
+	fset  *token.FileSet
+	files map[string]*ast.File
+	// vars maps every package-level variable to the file declaring it
+	vars map[string]string
+	// tests holds the test files, which are not part of files
+	tests map[string]*ast.File
+}
+
+// parseCommandPackage parses the Go files of dir, keeping tests apart.
+func parseCommandPackage(afs afero.Fs, dir string) (*commandPackage, error) {
+	entries, err := afero.ReadDir(afs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &commandPackage{
+		fset:  token.NewFileSet(),
+		files: map[string]*ast.File{},
+		vars:  map[string]string{},
+		tests: map[string]*ast.File{},
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".go" {
+			continue
+		}
+
+		filePath := filepath.Join(dir, entry.Name())
+		src, err := afero.ReadFile(afs, filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := parser.ParseFile(pkg.fset, filePath, src, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(entry.Name(), "_test.go") {
+			pkg.tests[filePath] = file
+			continue
+		}
+		pkg.files[filePath] = file
+
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					pkg.vars[name.Name] = filePath
+				}
+			}
+		}
+	}
+	return pkg, nil
+}
+
+// requireVar fails when name is not a package-level variable, listing the
+// command variables that are.
+func (pkg *commandPackage) requireVar(name string) error {
+	if _, ok := pkg.vars[name]; ok {
+		return nil
+	}
+
+	var commands []string
+	for v := range pkg.vars {
+		if strings.HasSuffix(v, "Cmd") {
+			commands = append(commands, v)
+		}
+	}
+	sort.Strings(commands)
+	return fmt.Errorf("parent command %s is not declared in package cmd, declared commands: %s", name, strings.Join(commands, ", "))
+}
+
+// paths returns the files of the package, sorted.
+func (pkg *commandPackage) paths() []string {
+	paths := make([]string, 0, len(pkg.files))
+	for filePath := range pkg.files {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// testPaths returns the test files of the package itself, sorted, leaving
+// out those of an external _test package.
+func (pkg *commandPackage) testPaths() []string {
+	var paths []string
+	for filePath, file := range pkg.tests {
+		if !strings.HasSuffix(file.Name.Name, "_test") {
+			paths = append(paths, filePath)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// commands returns the variables of the package holding a cobra.Command,
+// sorted.
+func (pkg *commandPackage) commands() []string {
+	var commands []string
+	for name, filePath := range pkg.vars {
+		if _, lit := commandLiteral(pkg.files[filePath], name); lit != nil && isCommandType(lit.Type) {
+			commands = append(commands, name)
+		}
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// isCommandType reports whether expr is cobra.Command.
+func isCommandType(expr ast.Expr) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Command" {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	return ok && x.Name == "cobra"
+}
+
+// commandVar returns the variable of the command called name, given as the
+// name of the command or as its variable: serve and serveCmd both give
+// serveCmd.
+func (pkg *commandPackage) commandVar(name string) (string, error) {
+	commands := pkg.commands()
+	for _, candidate := range []string{name, validateCmdName([]string{name}) + "Cmd"} {
+		if slices.Contains(commands, candidate) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("command %s is not declared in package cmd, declared commands: %s", name, strings.Join(commands, ", "))
+}
+
+// commandRegistration is a call to parent.AddCommand in an init() of file.
+type commandRegistration struct {
+	file     string
+	stmt     ast.Stmt
+	parent   string
+	children []string
+}
+
+// registrations returns every call to AddCommand in the init() functions of
+// the package, in file order.
+func (pkg *commandPackage) registrations() []commandRegistration {
+	var registrations []commandRegistration
+	for _, filePath := range pkg.paths() {
+		for _, decl := range pkg.files[filePath].Decls {
+			fn := initFunc(decl)
+			if fn == nil {
+				continue
+			}
+
+			for _, stmt := range fn.Body.List {
+				expr, ok := stmt.(*ast.ExprStmt)
+				if !ok {
+					continue
+				}
+				call, ok := ast.Unparen(expr.X).(*ast.CallExpr)
+				if !ok {
+					continue
+				}
+				fun, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok || fun.Sel.Name != "AddCommand" {
+					continue
+				}
+				parent, ok := fun.X.(*ast.Ident)
+				if !ok {
+					continue
+				}
+
+				registration := commandRegistration{file: filePath, stmt: stmt, parent: parent.Name}
+				for _, arg := range call.Args {
+					if ident, ok := arg.(*ast.Ident); ok {
+						registration.children = append(registration.children, ident.Name)
+					}
+				}
+				registrations = append(registrations, registration)
+			}
+		}
+	}
+	return registrations
+}
+
+// children returns the commands registered to parent.
+func (pkg *commandPackage) children(parent string) []string {
+	var children []string
+	for _, registration := range pkg.registrations() {
+		if registration.parent == parent {
+			children = append(children, registration.children...)
+		}
+	}
+	return children
+}
+
+// parent returns the command child is registered to, "" when it is not
+// registered.
+func (pkg *commandPackage) parent(child string) string {
+	for _, registration := range pkg.registrations() {
+		if slices.Contains(registration.children, child) {
+			return registration.parent
+		}
+	}
+	return ""
+}
+
+// groups returns the IDs of the groups added to cmdVar anywhere in the
+// package.
+func (pkg *commandPackage) groups(cmdVar string) []string {
+	var ids []string
+	for _, filePath := range pkg.paths() {
+		ast.Inspect(pkg.files[filePath], func(node ast.Node) bool {
+			if stmt, ok := node.(ast.Stmt); ok {
+				for _, arg := range methodCall(stmt, cmdVar, "AddGroup") {
+					ids = append(ids, groupID(arg))
+				}
+			}
+			return true
+		})
+	}
+	return ids
+}
+
+// groupTitle returns the title of the group id added to cmdVar anywhere in
+// the package, "" when cmdVar does not add it.
+func (pkg *commandPackage) groupTitle(cmdVar, id string) string {
+	var title string
+	for _, filePath := range pkg.paths() {
+		ast.Inspect(pkg.files[filePath], func(node ast.Node) bool {
+			if stmt, ok := node.(ast.Stmt); ok && title == "" {
+				for _, arg := range methodCall(stmt, cmdVar, "AddGroup") {
+					if groupID(arg) != id {
+						continue
+					}
+					if unary, ok := arg.(*ast.UnaryExpr); ok {
+						arg = unary.X
+					}
+					if kv := literalField(arg.(*ast.CompositeLit), "Title"); kv != nil {
+						title, _ = stringLit(kv.Value)
+					}
+				}
+			}
+			return title == ""
+		})
+	}
+	return title
+}
+
+// commandPath splits the name given to `cobra-cli add` into the names of
+// the commands leading to the new one: config/create/user gives config,
+// create and user.
+func commandPath(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+
+	var names []string
+	for _, name := range strings.Split(filepath.ToSlash(args[0]), "/") {
+		if name != "" && name != "." {
+			names = append(names, name)
+		}
+	}
+	return names
+}
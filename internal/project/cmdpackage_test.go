@@ -0,0 +1,131 @@
+package project
+
+import (
+	"bytes"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddNestedCommand(t *testing.T) {
+	viper.Set("license", "none")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+
+	project, err := NewProject([]string{"myproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project.SetPkgName("github.com/acme/myproject")
+
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.PrepareModels(); err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.CreateProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	cmdDir := filepath.Join(project.AbsolutePath, "cmd")
+
+	addCommand(t, fs, cmdDir, "config/create/user", "")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "config.go"), "rootCmd.AddCommand(configCmd)")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "create.go"), "configCmd.AddCommand(createCmd)")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "user.go"), "createCmd.AddCommand(userCmd)")
+
+	// existing intermediate commands are reused
+	generator = addCommand(t, fs, cmdDir, "config/delete", "")
+	if len(generator.Content) != 1 {
+		t.Fatalf("config was generated again: %d content(s)", len(generator.Content))
+	}
+	assertRegistered(t, fs, filepath.Join(cmdDir, "delete.go"), "configCmd.AddCommand(deleteCmd)")
+
+	addCommand(t, fs, cmdDir, "list", "userCmd")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "list.go"), "userCmd.AddCommand(listCmd)")
+
+	// existing commands are only reused under their own parent
+	for _, tt := range []struct{ name, parent, err string }{
+		{"create/group", "", "command createCmd is registered under configCmd, not rootCmd"},
+		{"config/group", "userCmd", "command configCmd is registered under rootCmd, not userCmd"},
+	} {
+		command, err := NewProject([]string{tt.name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		command.SetAbsolutePath(filepath.Join(cmdDir, filepath.FromSlash(tt.name)))
+
+		generator, err := NewProjectGenerator(fs, command)
+		if err != nil {
+			t.Fatal(err)
+		}
+		generator.Parent = tt.parent
+
+		err = generator.AddCommandProject()
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("add %s under %q: error = %v, want %q", tt.name, tt.parent, err, tt.err)
+		}
+	}
+	if stat(fs, filepath.Join(cmdDir, "group.go")) {
+		t.Fatal("group.go was written under the wrong parent")
+	}
+
+	// unknown parents are refused before anything is written
+	command, err := NewProject([]string{"show"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	command.SetAbsolutePath(filepath.Join(cmdDir, "show"))
+
+	generator, err = NewProjectGenerator(fs, command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator.Parent = "missingCmd"
+
+	err = generator.AddCommandProject()
+	if err == nil || !strings.Contains(err.Error(), "parent command missingCmd is not declared") {
+		t.Fatalf("AddCommandProject() error = %v, want an undeclared parent", err)
+	}
+	if stat(fs, filepath.Join(cmdDir, "show.go")) {
+		t.Fatal("show.go was written for an undeclared parent")
+	}
+}
+
+func addCommand(t *testing.T, fs afero.Fs, cmdDir, name, parent string) *Generator {
+	t.Helper()
+
+	command, err := NewProject([]string{name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	command.SetAbsolutePath(filepath.Join(cmdDir, filepath.FromSlash(name)))
+
+	generator, err := NewProjectGenerator(fs, command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator.Parent = parent
+
+	if err := generator.AddCommandProject(); err != nil {
+		t.Fatalf("add %s: %v", name, err)
+	}
+	return generator
+}
+
+func assertRegistered(t *testing.T, fs afero.Fs, filePath, registration string) {
+	t.Helper()
+
+	data, err := afero.ReadFile(fs, filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(registration)) {
+		t.Errorf("%s does not contain %s:\n%s", filePath, registration, data)
+	}
+}
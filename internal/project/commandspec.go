@@ -0,0 +1,379 @@
+package project
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CommandSpec describes what a generated command declares besides its name:
+// its help texts, flags, the validator of its positional arguments, its
+// aliases, the group it belongs to and the groups of its children.
+type CommandSpec struct {
+	Short      string   `yaml:"short,omitempty" json:"short,omitempty"`
+	Long       string   `yaml:"long,omitempty" json:"long,omitempty"`
+	Flags      []Flag   `yaml:"flags,omitempty" json:"flags,omitempty"`
+	Args       string   `yaml:"args,omitempty" json:"args,omitempty"`
+	Aliases    []string `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+	Hidden     bool     `yaml:"hidden,omitempty" json:"hidden,omitempty"`
+	Deprecated string   `yaml:"deprecated,omitempty" json:"deprecated,omitempty"`
+	Group      string   `yaml:"group,omitempty" json:"group,omitempty"`
+	Groups     []Group  `yaml:"groups,omitempty" json:"groups,omitempty"`
+}
+
+// Group is a group of commands shown together in the help of their parent.
+type Group struct {
+	ID    string `yaml:"id" json:"id"`
+	Title string `yaml:"title" json:"title"`
+}
+
+// Flag is a typed flag of a generated command, bound to a package variable.
+// Persistent flags are inherited by the children of the command.
+type Flag struct {
+	Name       string `yaml:"name" json:"name"`
+	Type       string `yaml:"type" json:"type"`
+	Default    string `yaml:"default,omitempty" json:"default,omitempty"`
+	Usage      string `yaml:"usage,omitempty" json:"usage,omitempty"`
+	Required   bool   `yaml:"required,omitempty" json:"required,omitempty"`
+	Persistent bool   `yaml:"persistent,omitempty" json:"persistent,omitempty"`
+}
+
+// flagType is how a Flag type is declared in Go.
+type flagType struct {
+	goType  string
+	fn      string
+	literal func(value string) (string, error)
+}
+
+var flagTypes = map[string]flagType{
+	"string":      {goType: "string", fn: "StringVar", literal: func(v string) (string, error) { return strconv.Quote(v), nil }},
+	"bool":        {goType: "bool", fn: "BoolVar", literal: boolLiteral},
+	"int":         {goType: "int", fn: "IntVar", literal: intLiteral},
+	"int64":       {goType: "int64", fn: "Int64Var", literal: intLiteral},
+	"float64":     {goType: "float64", fn: "Float64Var", literal: floatLiteral},
+	"duration":    {goType: "time.Duration", fn: "DurationVar", literal: durationLiteral},
+	"stringSlice": {goType: "[]string", fn: "StringSliceVar", literal: stringSliceLiteral},
+	"intSlice":    {goType: "[]int", fn: "IntSliceVar", literal: intSliceLiteral},
+}
+
+var flagName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*$`)
+
+// ParseFlag parses a flag given as name:type[:default[:usage]], where any
+// part may be double-quoted to hold colons. The type defaults to string.
+func ParseFlag(value string) (Flag, error) {
+	parts := splitFlag(value)
+
+	flag := Flag{Name: parts[0], Type: "string"}
+	if len(parts) > 1 && parts[1] != "" {
+		flag.Type = parts[1]
+	}
+	if len(parts) > 2 {
+		flag.Default = parts[2]
+	}
+	if len(parts) > 3 {
+		flag.Usage = parts[3]
+	}
+
+	if err := flag.validate(); err != nil {
+		return Flag{}, fmt.Errorf("flag %q: %w", value, err)
+	}
+	return flag, nil
+}
+
+// splitFlag splits value on the colons outside double quotes into at most
+// four parts, the last one keeping the rest, and unquotes every part.
+func splitFlag(value string) []string {
+	var (
+		parts  []string
+		part   strings.Builder
+		quoted bool
+	)
+
+	for _, r := range value {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ':' && !quoted && len(parts) < 3:
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteRune(r)
+		}
+	}
+	return append(parts, part.String())
+}
+
+func (f Flag) validate() error {
+	if !flagName.MatchString(f.Name) {
+		return fmt.Errorf("invalid name %q", f.Name)
+	}
+
+	kind, ok := flagTypes[f.Type]
+	if !ok {
+		types := make([]string, 0, len(flagTypes))
+		for name := range flagTypes {
+			types = append(types, name)
+		}
+		slices.Sort(types)
+		return fmt.Errorf("unknown type %q (use one of: %s)", f.Type, strings.Join(types, ", "))
+	}
+
+	if _, err := kind.literal(f.Default); err != nil {
+		return fmt.Errorf("invalid %s default %q: %w", f.Type, f.Default, err)
+	}
+	return nil
+}
+
+// GoType returns the Go type of the variable the flag is bound to.
+func (f Flag) GoType() string {
+	return flagTypes[f.Type].goType
+}
+
+// Func returns the method of pflag.FlagSet declaring the flag.
+func (f Flag) Func() string {
+	return flagTypes[f.Type].fn
+}
+
+// FlagSet returns the method of cobra.Command holding the flag.
+func (f Flag) FlagSet() string {
+	if f.Persistent {
+		return "PersistentFlags"
+	}
+	return "Flags"
+}
+
+// MarkRequired returns the method of cobra.Command marking the flag as
+// required.
+func (f Flag) MarkRequired() string {
+	if f.Persistent {
+		return "MarkPersistentFlagRequired"
+	}
+	return "MarkFlagRequired"
+}
+
+// Value returns the default of the flag as a Go expression.
+func (f Flag) Value() string {
+	literal, _ := flagTypes[f.Type].literal(f.Default)
+	return literal
+}
+
+func boolLiteral(value string) (string, error) {
+	if value == "" {
+		return "false", nil
+	}
+	b, err := strconv.ParseBool(value)
+	return strconv.FormatBool(b), err
+}
+
+func intLiteral(value string) (string, error) {
+	if value == "" {
+		return "0", nil
+	}
+	i, err := strconv.ParseInt(value, 0, 64)
+	return strconv.FormatInt(i, 10), err
+}
+
+func floatLiteral(value string) (string, error) {
+	if value == "" {
+		return "0", nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	return strconv.FormatFloat(f, 'g', -1, 64), err
+}
+
+// durationLiteral returns value as a multiple of the largest unit dividing
+// it: 90s gives 90 * time.Second and 2h gives 2 * time.Hour.
+func durationLiteral(value string) (string, error) {
+	if value == "" {
+		return "0", nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return "", err
+	}
+
+	for _, unit := range []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+		{time.Microsecond, "time.Microsecond"},
+	} {
+		if d != 0 && d%unit.d == 0 {
+			return fmt.Sprintf("%d * %s", d/unit.d, unit.name), nil
+		}
+	}
+	return strconv.FormatInt(int64(d), 10), nil
+}
+
+func stringSliceLiteral(value string) (string, error) {
+	if value == "" {
+		return "nil", nil
+	}
+
+	items := strings.Split(value, ",")
+	for i, item := range items {
+		items[i] = strconv.Quote(strings.TrimSpace(item))
+	}
+	return "[]string{" + strings.Join(items, ", ") + "}", nil
+}
+
+func intSliceLiteral(value string) (string, error) {
+	if value == "" {
+		return "nil", nil
+	}
+
+	items := strings.Split(value, ",")
+	for i, item := range items {
+		literal, err := intLiteral(strings.TrimSpace(item))
+		if err != nil {
+			return "", err
+		}
+		items[i] = literal
+	}
+	return "[]int{" + strings.Join(items, ", ") + "}", nil
+}
+
+// argsValidators maps the kinds of positional argument validation to the
+// cobra validator and the number of bounds it takes.
+var argsValidators = map[string]struct {
+	fn     string
+	bounds int
+}{
+	"none":  {"cobra.NoArgs", 0},
+	"any":   {"cobra.ArbitraryArgs", 0},
+	"valid": {"cobra.OnlyValidArgs", 0},
+	"exact": {"cobra.ExactArgs", 1},
+	"min":   {"cobra.MinimumNArgs", 1},
+	"max":   {"cobra.MaximumNArgs", 1},
+	"range": {"cobra.RangeArgs", 2},
+}
+
+// ParseArgs checks a positional argument validation given as none, any,
+// valid, exact:N, min:N, max:N or range:MIN:MAX.
+func ParseArgs(value string) (string, error) {
+	if _, err := argsValidator(value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func argsValidator(value string) (string, error) {
+	parts := strings.Split(value, ":")
+
+	validator, ok := argsValidators[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("args %q: unknown validation %q (use none, any, valid, exact:N, min:N, max:N or range:MIN:MAX)", value, parts[0])
+	}
+	if len(parts)-1 != validator.bounds {
+		return "", fmt.Errorf("args %q: %s takes %d bound(s)", value, parts[0], validator.bounds)
+	}
+
+	bounds := parts[1:]
+	for _, bound := range bounds {
+		if n, err := strconv.Atoi(bound); err != nil || n < 0 {
+			return "", fmt.Errorf("args %q: invalid bound %q", value, bound)
+		}
+	}
+	if len(bounds) == 2 {
+		low, _ := strconv.Atoi(bounds[0])
+		high, _ := strconv.Atoi(bounds[1])
+		if low > high {
+			return "", fmt.Errorf("args %q: minimum is greater than maximum", value)
+		}
+	}
+
+	if validator.bounds == 0 {
+		return validator.fn, nil
+	}
+	return validator.fn + "(" + strings.Join(bounds, ", ") + ")", nil
+}
+
+// ArgsValidator returns the cobra validator of the positional arguments,
+// such as cobra.ExactArgs(1), or "" when they are not validated.
+func (s CommandSpec) ArgsValidator() string {
+	if s.Args == "" {
+		return ""
+	}
+	validator, _ := argsValidator(s.Args)
+	return validator
+}
+
+// Imports returns the packages the declarations of the spec need besides
+// cobra.
+func (s CommandSpec) Imports() []string {
+	for _, flag := range s.Flags {
+		if flag.Type == "duration" {
+			return []string{"time"}
+		}
+	}
+	return nil
+}
+
+// RequireFlags marks the named flags as required, failing on a name that is
+// not a flag of the spec.
+func (s *CommandSpec) RequireFlags(names []string) error {
+	for _, name := range names {
+		i := slices.IndexFunc(s.Flags, func(flag Flag) bool { return flag.Name == name })
+		if i < 0 {
+			return fmt.Errorf("required flag %q is not declared with --flag", name)
+		}
+		s.Flags[i].Required = true
+	}
+	return nil
+}
+
+// Validate checks every part of the spec.
+func (s CommandSpec) Validate() error {
+	seen := map[string]bool{}
+	for _, flag := range s.Flags {
+		if err := flag.validate(); err != nil {
+			return fmt.Errorf("flag %s: %w", flag.Name, err)
+		}
+		if seen[flag.Name] {
+			return fmt.Errorf("flag %s is declared twice", flag.Name)
+		}
+		seen[flag.Name] = true
+	}
+
+	if s.Args != "" {
+		if _, err := argsValidator(s.Args); err != nil {
+			return err
+		}
+	}
+
+	for _, alias := range s.Aliases {
+		if alias == "" || strings.ContainsAny(alias, " \t\"") {
+			return fmt.Errorf("invalid alias %q", alias)
+		}
+	}
+
+	for _, group := range s.Groups {
+		if group.ID == "" {
+			return fmt.Errorf("group %q has no id", group.Title)
+		}
+	}
+	return nil
+}
+
+// LongLiteral returns the long description as a Go raw string, or as an
+// interpreted one when it holds a backquote.
+func (s CommandSpec) LongLiteral() string {
+	if strings.Contains(s.Long, "`") {
+		return strconv.Quote(s.Long)
+	}
+	return "`" + s.Long + "`"
+}
+
+// FlagVar returns the package variable flag is bound to, named after the
+// command: the env flag of deploy is bound to deployEnv.
+func (c Command) FlagVar(flag Flag) string {
+	return camelCase(c.CmdName + "-" + flag.Name)
+}
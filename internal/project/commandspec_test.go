@@ -0,0 +1,194 @@
+package project
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFlag(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Flag
+		err   bool
+	}{
+		{value: "name", want: Flag{Name: "name", Type: "string"}},
+		{value: `env:string:staging:"target env"`, want: Flag{Name: "env", Type: "string", Default: "staging", Usage: "target env"}},
+		{value: `url::"http://localhost:8080":where to: listen`, want: Flag{Name: "url", Type: "string", Default: "http://localhost:8080", Usage: "where to: listen"}},
+		{value: "replicas:int:3", want: Flag{Name: "replicas", Type: "int", Default: "3"}},
+		{value: "replicas:int:three", err: true},
+		{value: "timeout:duration:1m", want: Flag{Name: "timeout", Type: "duration", Default: "1m"}},
+		{value: "ratio:decimal", err: true},
+		{value: "-bad:string", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseFlag(tt.value)
+			if (err != nil) != tt.err {
+				t.Fatalf("ParseFlag() error = %v, want error %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFlag() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagValue(t *testing.T) {
+	tests := []struct {
+		flag Flag
+		want string
+	}{
+		{Flag{Type: "string"}, `""`},
+		{Flag{Type: "bool", Default: "true"}, "true"},
+		{Flag{Type: "int64", Default: "0x10"}, "16"},
+		{Flag{Type: "float64", Default: "0.50"}, "0.5"},
+		{Flag{Type: "duration", Default: "90s"}, "90 * time.Second"},
+		{Flag{Type: "duration", Default: "2h"}, "2 * time.Hour"},
+		{Flag{Type: "duration", Default: "1.5ms"}, "1500 * time.Microsecond"},
+		{Flag{Type: "stringSlice", Default: "a, b"}, `[]string{"a", "b"}`},
+		{Flag{Type: "intSlice"}, "nil"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.flag.Value(); got != tt.want {
+			t.Errorf("%s %q: Value() = %s, want %s", tt.flag.Type, tt.flag.Default, got, tt.want)
+		}
+	}
+}
+
+func TestArgsValidator(t *testing.T) {
+	tests := []struct {
+		args string
+		want string
+		err  bool
+	}{
+		{args: "none", want: "cobra.NoArgs"},
+		{args: "exact:1", want: "cobra.ExactArgs(1)"},
+		{args: "range:1:3", want: "cobra.RangeArgs(1, 3)"},
+		{args: "range:3:1", err: true},
+		{args: "exact", err: true},
+		{args: "min:-1", err: true},
+		{args: "some", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := argsValidator(tt.args)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("argsValidator(%q) = %q, %v, want %q, error %v", tt.args, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestAddCommandSpec(t *testing.T) {
+	viper.Set("license", "none")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	cmdDir := createTestProject(t, fs)
+
+	spec := CommandSpec{Args: "exact:1", Aliases: []string{"dp"}}
+	for _, value := range []string{`env:string:staging:"target env"`, "replicas:int:3", "timeout:duration:30s"} {
+		flag, err := ParseFlag(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		spec.Flags = append(spec.Flags, flag)
+	}
+	if err := spec.RequireFlags([]string{"env"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.RequireFlags([]string{"region"}); err == nil {
+		t.Fatal("an undeclared flag was marked as required")
+	}
+
+	command, err := NewProject([]string{"deploy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	command.SetAbsolutePath(filepath.Join(cmdDir, "deploy"))
+
+	generator, err := NewProjectGenerator(fs, command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator.Spec = spec
+	if err := generator.AddCommandProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertFileMatchesGolden(t, fs, filepath.Join(cmdDir, "deploy.go"), "testdata/add_command_spec.golden")
+}
+
+func TestAddCommandGroup(t *testing.T) {
+	viper.Set("license", "none")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	cmdDir := createTestProject(t, fs)
+
+	addGrouped := func(name, group, title string) {
+		t.Helper()
+
+		command, err := NewProject([]string{name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		command.SetAbsolutePath(filepath.Join(cmdDir, filepath.FromSlash(name)))
+
+		generator, err := NewProjectGenerator(fs, command)
+		if err != nil {
+			t.Fatal(err)
+		}
+		generator.Spec = CommandSpec{Group: group}
+		generator.GroupTitle = title
+		if err := generator.AddCommandProject(); err != nil {
+			t.Fatalf("add %s: %v", name, err)
+		}
+	}
+
+	addGrouped("serve", "server", "Server Commands")
+	addGrouped("status", "server", "Ignored")
+	addGrouped("jobs/run", "ops", "")
+
+	rootGo, err := afero.ReadFile(fs, filepath.Join(cmdDir, "root.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(rootGo), "AddGroup"); n != 1 {
+		t.Errorf("root.go adds %d groups, want 1:\n%s", n, rootGo)
+	}
+	assertRegistered(t, fs, filepath.Join(cmdDir, "root.go"), `rootCmd.AddGroup(&cobra.Group{ID: "server", Title: "Server Commands"})`)
+	assertRegistered(t, fs, filepath.Join(cmdDir, "serve.go"), `GroupID: "server",`)
+	assertRegistered(t, fs, filepath.Join(cmdDir, "status.go"), `GroupID: "server",`)
+
+	// the parent generated along adds the group itself
+	assertRegistered(t, fs, filepath.Join(cmdDir, "jobs.go"), `jobsCmd.AddGroup(&cobra.Group{ID: "ops", Title: "Ops Commands"})`)
+	assertRegistered(t, fs, filepath.Join(cmdDir, "run.go"), `GroupID: "ops",`)
+}
+
+// createTestProject generates a project in fs and returns its cmd directory.
+func createTestProject(t *testing.T, fs afero.Fs) string {
+	t.Helper()
+
+	project, err := NewProject([]string{"myproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project.SetPkgName("github.com/acme/myproject")
+
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.PrepareModels(); err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.CreateProject(); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(project.AbsolutePath, "cmd")
+}
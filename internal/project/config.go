@@ -0,0 +1,119 @@
+package project
+
+import (
+	"fmt"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigKeys are the settings of cobra-cli read from its config file, from
+// the COBRA_ environment variables and, for most, from a flag.
+var ConfigKeys = []string{"author", "license", "year", "templates"}
+
+// configEnvPrefix prefixes the environment variables overriding the config:
+// COBRA_AUTHOR overrides author.
+const configEnvPrefix = "COBRA"
+
+// ConfigSearchPath returns where the config file is looked for, in order:
+// a .cobra.yaml in wd or one of its parents up to the root of the module
+// holding wd, then $XDG_CONFIG_HOME/cobra-cli/config.yaml, then
+// ~/.cobra.yaml.
+func ConfigSearchPath(afs afero.Fs, wd string) []string {
+	var search []string
+	for dir := filepath.Clean(wd); ; dir = filepath.Dir(dir) {
+		search = append(search, filepath.Join(dir, ".cobra.yaml"))
+		if stat(afs, filepath.Join(dir, "go.mod")) {
+			break
+		}
+		if dir == filepath.Dir(dir) {
+			// outside of a module, only wd is a project
+			search = search[:1]
+			break
+		}
+	}
+
+	home, _ := os.UserHomeDir()
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" && home != "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	if configHome != "" {
+		search = append(search, filepath.Join(configHome, "cobra-cli", "config.yaml"))
+	}
+	if home != "" {
+		search = append(search, filepath.Join(home, ".cobra.yaml"))
+	}
+	return search
+}
+
+// LoadConfig reads the config file into viper and makes the COBRA_
+// environment variables override it. The file is configFile when it is set,
+// and must then exist, or else the first of ConfigSearchPath that exists.
+// It returns the file read, "" when there is none.
+func LoadConfig(afs afero.Fs, configFile, wd string) (string, error) {
+	viper.SetFs(afs)
+	viper.SetEnvPrefix(configEnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+
+	if configFile == "" {
+		for _, candidate := range ConfigSearchPath(afs, wd) {
+			if stat(afs, candidate) {
+				configFile = candidate
+				break
+			}
+		}
+	}
+	if configFile == "" {
+		return "", nil
+	}
+
+	if !stat(afs, configFile) {
+		return "", fmt.Errorf("config file %s not found", configFile)
+	}
+	viper.SetConfigFile(configFile)
+	if filepath.Ext(configFile) == "" {
+		viper.SetConfigType("yaml")
+	}
+	if err := viper.ReadInConfig(); err != nil {
+		return "", fmt.Errorf("read config %s: %w", configFile, err)
+	}
+	return configFile, nil
+}
+
+// ConfigValue is the effective value of a config key and where it comes
+// from: a flag, an environment variable, the config file or the default.
+type ConfigValue struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// EffectiveConfig returns the value of every ConfigKeys, as viper resolves
+// it: a flag of flags set on the command line first, then the environment,
+// then configFile, then the default.
+func EffectiveConfig(flags *pflag.FlagSet, configFile string) []ConfigValue {
+	values := make([]ConfigValue, 0, len(ConfigKeys))
+	for _, key := range ConfigKeys {
+		value := ConfigValue{Key: key, Value: viper.GetString(key), Source: "default"}
+		if key == "license" && isCustomLicense() {
+			value.Value = customLicenseCode
+		}
+
+		env := configEnvPrefix + "_" + strings.ToUpper(key)
+		switch {
+		case flags != nil && flags.Changed(key):
+			value.Source = "flag --" + key
+		case os.Getenv(env) != "":
+			value.Source = "env " + env
+		case viper.InConfig(key):
+			value.Source = "config " + configFile
+		}
+		values = append(values, value)
+	}
+	return values
+}
@@ -0,0 +1,79 @@
+package project
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"reflect"
+	"testing"
+)
+
+func TestConfigSearchPath(t *testing.T) {
+	t.Setenv("HOME", "/home/u")
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "/work/app/go.mod", []byte("module app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"/work/app/cmd/.cobra.yaml", "/work/app/.cobra.yaml", "/home/u/.config/cobra-cli/config.yaml", "/home/u/.cobra.yaml"}
+	if got := ConfigSearchPath(fs, "/work/app/cmd"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got search path %q, want %q", got, want)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	want = []string{"/tmp/.cobra.yaml", "/xdg/cobra-cli/config.yaml", "/home/u/.cobra.yaml"}
+	if got := ConfigSearchPath(fs, "/tmp"); !reflect.DeepEqual(got, want) {
+		t.Errorf("outside of a module, got search path %q, want %q", got, want)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("HOME", "/home/u")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("COBRA_LICENSE", "mit")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	for name, content := range map[string]string{
+		"/home/u/.cobra.yaml":                   "author: Home\n",
+		"/home/u/.config/cobra-cli/config.yaml": "author: XDG\nlicense: apache2\nyear: 2020\n",
+	} {
+		if err := afero.WriteFile(fs, name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	flags := pflag.NewFlagSet("cobra-cli", pflag.ContinueOnError)
+	flags.String("templates", "", "")
+	flags.String("license", "none", "")
+	if err := viper.BindPFlags(flags); err != nil {
+		t.Fatal(err)
+	}
+	if err := flags.Parse([]string{"--templates", "/tpl"}); err != nil {
+		t.Fatal(err)
+	}
+
+	configFile, err := LoadConfig(fs, "", "/tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configFile != "/home/u/.config/cobra-cli/config.yaml" {
+		t.Fatalf("read %s, want the XDG config", configFile)
+	}
+
+	want := []ConfigValue{
+		{Key: "author", Value: "XDG", Source: "config " + configFile},
+		{Key: "license", Value: "mit", Source: "env COBRA_LICENSE"},
+		{Key: "year", Value: "2020", Source: "config " + configFile},
+		{Key: "templates", Value: "/tpl", Source: "flag --templates"},
+	}
+	if got := EffectiveConfig(flags, configFile); !reflect.DeepEqual(got, want) {
+		t.Errorf("got config\n%+v\nwant\n%+v", got, want)
+	}
+
+	if _, err := LoadConfig(fs, "/missing.yaml", "/tmp"); err == nil {
+		t.Error("a missing --config file was accepted")
+	}
+}
@@ -0,0 +1,119 @@
+package project
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConflictPolicy decides what happens when a file about to be generated
+// already exists on disk.
+type ConflictPolicy string
+
+const (
+	ConflictFail      ConflictPolicy = "fail"
+	ConflictSkip      ConflictPolicy = "skip"
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	ConflictNew       ConflictPolicy = "new"
+)
+
+// ConflictPolicies lists every supported ConflictPolicy.
+var ConflictPolicies = []ConflictPolicy{ConflictFail, ConflictSkip, ConflictOverwrite, ConflictNew}
+
+// ParseConflictPolicy returns the ConflictPolicy named by value.
+func ParseConflictPolicy(value string) (ConflictPolicy, error) {
+	for _, policy := range ConflictPolicies {
+		if string(policy) == value {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown conflict policy %q (use one of: %s)", value, joinPolicies(ConflictPolicies))
+}
+
+func joinPolicies(policies []ConflictPolicy) string {
+	names := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		names = append(names, string(policy))
+	}
+	return strings.Join(names, ", ")
+}
+
+// Conflict records a generated file whose target already existed and how
+// it was resolved.
+type Conflict struct {
+	FilePath string
+	Policy   ConflictPolicy
+}
+
+// Target returns the path the content is written to, or "" when nothing is
+// written.
+func (c Conflict) Target() string {
+	switch c.Policy {
+	case ConflictOverwrite:
+		return c.FilePath
+	case ConflictNew:
+		return c.FilePath + ".new"
+	default:
+		return ""
+	}
+}
+
+func (c Conflict) String() string {
+	switch c.Policy {
+	case ConflictSkip:
+		return fmt.Sprintf("%s: exists, skipped", c.FilePath)
+	case ConflictOverwrite:
+		return fmt.Sprintf("%s: exists, overwritten", c.FilePath)
+	case ConflictNew:
+		return fmt.Sprintf("%s: exists, written to %s", c.FilePath, c.Target())
+	default:
+		return fmt.Sprintf("%s: exists", c.FilePath)
+	}
+}
+
+// ConflictError is returned when the ConflictFail policy finds existing
+// files. Nothing has been written when it is returned.
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	paths := make([]string, 0, len(e.Conflicts))
+	for _, conflict := range e.Conflicts {
+		paths = append(paths, conflict.FilePath)
+	}
+	return fmt.Sprintf("refusing to overwrite %d existing file(s), use --on-conflict to choose a policy: %s",
+		len(paths), strings.Join(paths, ", "))
+}
+
+// resolveConflicts records every prepared Content whose target exists and
+// fails, without writing anything, when the policy is ConflictFail.
+func (g *Generator) resolveConflicts() error {
+	g.Conflicts = nil
+
+	for _, content := range g.Content {
+		if content.Dirty || !stat(g.Afs, content.FilePath) {
+			continue
+		}
+
+		g.Conflicts = append(g.Conflicts, Conflict{
+			FilePath: content.FilePath,
+			Policy:   g.OnConflict,
+		})
+	}
+
+	if g.OnConflict == ConflictFail && len(g.Conflicts) > 0 {
+		return &ConflictError{Conflicts: g.Conflicts}
+	}
+	return nil
+}
+
+// targetPath returns the path content should be written to under the
+// current policy, or "" when it must be left alone.
+func (g *Generator) targetPath(content Content) string {
+	for _, conflict := range g.Conflicts {
+		if conflict.FilePath == content.FilePath {
+			return conflict.Target()
+		}
+	}
+	return content.FilePath
+}
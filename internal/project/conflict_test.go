@@ -0,0 +1,84 @@
+package project
+
+import (
+	"errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderTemplateConflicts(t *testing.T) {
+	viper.Set("license", "mit")
+	defer viper.Reset()
+
+	project, err := NewProject([]string{"myproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project.SetPkgName("github.com/acme/myproject")
+
+	tests := []struct {
+		policy  ConflictPolicy
+		wantErr bool
+		want    string
+		wantNew bool
+	}{
+		{policy: ConflictFail, wantErr: true, want: "hand written"},
+		{policy: ConflictSkip, want: "hand written"},
+		{policy: ConflictNew, want: "hand written", wantNew: true},
+		{policy: ConflictOverwrite},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			rootGo := filepath.Join(project.AbsolutePath, "cmd", "root.go")
+			if err := afero.WriteFile(fs, rootGo, []byte("hand written"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			generator, err := NewProjectGenerator(fs, project)
+			if err != nil {
+				t.Fatal(err)
+			}
+			generator.OnConflict = tt.policy
+
+			if err := generator.PrepareModels(); err != nil {
+				t.Fatal(err)
+			}
+
+			err = generator.renderTemplate()
+			var conflictErr *ConflictError
+			if tt.wantErr != errors.As(err, &conflictErr) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(generator.Conflicts) != 1 || generator.Conflicts[0].FilePath != rootGo {
+				t.Fatalf("expected a single conflict on %s, got %v", rootGo, generator.Conflicts)
+			}
+
+			data, err := afero.ReadFile(fs, rootGo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != "" && string(data) != tt.want {
+				t.Fatalf("%s was modified", rootGo)
+			}
+			if tt.want == "" && string(data) == "hand written" {
+				t.Fatalf("%s was not overwritten", rootGo)
+			}
+
+			if exists, _ := afero.Exists(fs, rootGo+".new"); exists != tt.wantNew {
+				t.Fatalf("expected %s.new to exist: %v", rootGo, tt.wantNew)
+			}
+
+			// a failing policy must not write anything at all
+			mainGo := filepath.Join(project.AbsolutePath, "main.go")
+			if exists, _ := afero.Exists(fs, mainGo); exists == tt.wantErr {
+				t.Fatalf("unexpected state of %s: exists=%v", mainGo, exists)
+			}
+		})
+	}
+}
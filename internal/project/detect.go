@@ -0,0 +1,176 @@
+package project
+
+import (
+	"crypto/md5"
+	"fmt"
+	"github.com/spf13/afero"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// minLicenseConfidence is the similarity from which the text of a LICENSE
+// file is taken for the license it is closest to.
+const minLicenseConfidence = 0.85
+
+// licenseFiles are the names of the file holding the license of a project.
+var licenseFiles = []string{"LICENSE", "LICENSE.txt", "LICENSE.md", "COPYING"}
+
+var (
+	// templateAction matches the actions of a license template.
+	templateAction = regexp.MustCompile(`{{.*?}}`)
+
+	// copyrightLine matches the copyright lines of a license text, which
+	// vary from project to project.
+	copyrightLine = regexp.MustCompile(`(?im)^\s*copyright\s*(©|\(c\)|\d{4}).*$`)
+)
+
+// LicenseMatch is the license identified from the LICENSE file of a
+// project. Confidence is the similarity of the file with the license, from
+// 0 to 1, and Copyright the copyright line of the file, "" when it has none.
+type LicenseMatch struct {
+	File       string
+	License    *License
+	Confidence float64
+	Copyright  string
+}
+
+// Confident reports whether the file is close enough to License to be
+// taken for it.
+func (m *LicenseMatch) Confident() bool {
+	return m.License != nil && m.Confidence >= minLicenseConfidence
+}
+
+// Comment returns the text of the license header of the project: the
+// copyright line of the file, or else the one of the config, followed by
+// the header of License.
+func (m *LicenseMatch) Comment() string {
+	copyright := m.Copyright
+	if copyright == "" {
+		copyright = m.License.Copyright
+	}
+	return strings.TrimSpace(copyright + "\n" + m.License.Header)
+}
+
+// DetectLicense identifies the license of the project holding dir from its
+// LICENSE file, found in dir or one of its parents up to the module root.
+func (g *Generator) DetectLicense(dir string) (*LicenseMatch, error) {
+	file, err := findLicenseFile(g.Afs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := afero.ReadFile(g.Afs, file)
+	if err != nil {
+		return nil, err
+	}
+
+	match := identifyLicense(contentLicenses(g.Templates), string(text))
+	match.File = file
+	return match, nil
+}
+
+// findLicenseFile returns the first of licenseFiles found in dir or one of
+// its parents, up to the root of the module holding dir.
+func findLicenseFile(afs afero.Fs, dir string) (string, error) {
+	for dir = filepath.Clean(dir); ; dir = filepath.Dir(dir) {
+		for _, name := range licenseFiles {
+			if file := filepath.Join(dir, name); stat(afs, file) {
+				return file, nil
+			}
+		}
+		if stat(afs, filepath.Join(dir, "go.mod")) || dir == filepath.Dir(dir) {
+			return "", fmt.Errorf("no LICENSE file found: %w", fs.ErrNotExist)
+		}
+	}
+}
+
+// identifyLicense returns the license of licenses whose body text is the
+// most similar to. A text equal to a body once normalized is found by its
+// HashLicense, the others are compared word by word.
+func identifyLicense(licenses map[string]*License, text string) *LicenseMatch {
+	words := licenseWords(text)
+	hash := hashLicenseWords(words)
+
+	match := &LicenseMatch{}
+	for _, key := range slices.Sorted(maps.Keys(licenses)) {
+		license := licenses[key]
+		if license.Body == "" {
+			continue
+		}
+
+		confidence := 1.0
+		if license.HashLicense != hash {
+			confidence = similarity(words, licenseWords(license.Body))
+		}
+		if confidence > match.Confidence {
+			match.License, match.Confidence = license, confidence
+		}
+	}
+
+	if match.License != nil {
+		match.Copyright = projectCopyright(text, match.License.Body)
+	}
+	return match
+}
+
+// projectCopyright returns the first copyright line of text that is not
+// one of body, the license text it was identified as.
+func projectCopyright(text, body string) string {
+	quoted := map[string]bool{}
+	for _, line := range copyrightLine.FindAllString(body, -1) {
+		quoted[strings.TrimSpace(line)] = true
+	}
+	for _, line := range copyrightLine.FindAllString(text, -1) {
+		if line = strings.TrimSpace(line); !quoted[line] {
+			return line
+		}
+	}
+	return ""
+}
+
+// licenseWords returns the words of a license text, lower-cased, without
+// its copyright lines and template actions.
+func licenseWords(text string) []string {
+	text = templateAction.ReplaceAllString(text, "")
+	text = copyrightLine.ReplaceAllString(text, "")
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// hashLicenseWords returns the HashLicense of a license text of words, so
+// that texts differing only by their copyright and layout hash alike.
+func hashLicenseWords(words []string) string {
+	return fmt.Sprintf("%X", md5.Sum([]byte(strings.Join(words, " "))))
+}
+
+// similarity returns the Sørensen–Dice coefficient of the sequences of
+// three words of a and b: 1 for the same text, 0 for texts sharing none.
+func similarity(a, b []string) float64 {
+	shingles := func(words []string) map[string]int {
+		counts := map[string]int{}
+		for i := 0; i+3 <= len(words); i++ {
+			counts[strings.Join(words[i:i+3], " ")]++
+		}
+		return counts
+	}
+
+	countsA, countsB := shingles(a), shingles(b)
+	var common, total int
+	for shingle, count := range countsA {
+		common += min(count, countsB[shingle])
+		total += count
+	}
+	for _, count := range countsB {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	return 2 * float64(common) / float64(total)
+}
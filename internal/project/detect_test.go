@@ -0,0 +1,78 @@
+package project
+
+import (
+	"bytes"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestIdentifyLicense(t *testing.T) {
+	licenses := contentLicenses(templates)
+
+	for key, license := range licenses {
+		if license.Body == "" {
+			continue
+		}
+		t.Run(key, func(t *testing.T) {
+			var text bytes.Buffer
+			tmpl := template.Must(template.New(key).Parse(license.Body))
+			if err := tmpl.Execute(&text, &License{Copyright: "Copyright © 2019 Jane Doe"}); err != nil {
+				t.Fatal(err)
+			}
+			// reindented with CRLF, as LICENSE files found in the wild often are
+			reindented := strings.ReplaceAll(text.String(), "\n", "\r\n    ")
+
+			match := identifyLicense(licenses, reindented)
+			if match.License != license || match.Confidence != 1 {
+				t.Fatalf("identified as %v with a confidence of %.2f", match.License, match.Confidence)
+			}
+			if strings.Contains(license.Body, "{{ .Copyright }}") && match.Copyright != "Copyright © 2019 Jane Doe" {
+				t.Errorf("got copyright %q", match.Copyright)
+			}
+		})
+	}
+
+	// the copyright line quoted by the GPL is not the one of the project
+	if match := identifyLicense(licenses, licenses["gpl3"].Body); match.Copyright != "" {
+		t.Errorf("got copyright %q of the GPL text", match.Copyright)
+	}
+
+	// BSD-2-Clause is BSD-3-Clause without its third clause
+	bsd := strings.Replace(licenses["bsd3"].Body, "endorse", "promote", 1)
+	if match := identifyLicense(licenses, bsd); match.License.SPDX != "BSD-3-Clause" || !match.Confident() || match.Confidence == 1 {
+		t.Errorf("edited BSD-3-Clause identified as %s with a confidence of %.2f", match.License.SPDX, match.Confidence)
+	}
+
+	if match := identifyLicense(licenses, "All rights reserved. Do not copy."); match.Confident() {
+		t.Errorf("a proprietary notice identified as %s", match.License.SPDX)
+	}
+}
+
+func TestAddCommandDetectsLicense(t *testing.T) {
+	viper.Set("license", "none")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	cmdDir := createTestProject(t, fs)
+	root := filepath.Dir(cmdDir)
+
+	mit := strings.Replace(getLicenseBody(templates, "mit"), "{{ .Copyright }}", "Copyright (c) 2019 Jane Doe", 1)
+	if err := afero.WriteFile(fs, filepath.Join(root, "LICENSE"), []byte(mit), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	generator := addCommand(t, fs, cmdDir, "serve", "rootCmd")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "serve.go"), "/*\nCopyright (c) 2019 Jane Doe\nPermission is hereby granted, free of charge")
+
+	match, err := generator.DetectLicense(cmdDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.File != filepath.Join(root, "LICENSE") || match.License.SPDX != "MIT" {
+		t.Errorf("detected %s in %s", match.License.SPDX, match.File)
+	}
+}
@@ -0,0 +1,199 @@
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/spf13/afero"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around every change.
+const diffContext = 3
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp is a single line of an edit script turning a into b. A and B are
+// the line indexes in a and b; only the one matching Kind is meaningful
+// for deletions and insertions.
+type diffOp struct {
+	Kind diffKind
+	A, B int
+	Line string
+}
+
+// splitLines splits content into lines, keeping the line terminators so
+// that a missing final newline shows up as a change.
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(ensureLF(content)), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script from a to b using the longest
+// common subsequence of lines.
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{Kind: diffEqual, A: i, B: j, Line: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{Kind: diffDelete, A: i, B: j, Line: a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{Kind: diffInsert, A: i, B: j, Line: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{Kind: diffDelete, A: i, B: j, Line: a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{Kind: diffInsert, A: i, B: j, Line: b[j]})
+	}
+	return ops
+}
+
+// unifiedDiff returns the unified diff turning contentA into contentB, or
+// "" when both are equal once line endings are normalized.
+func unifiedDiff(nameA, nameB string, contentA, contentB []byte) string {
+	ops := diffLines(splitLines(contentA), splitLines(contentB))
+
+	var changes []int
+	for k, op := range ops {
+		if op.Kind != diffEqual {
+			changes = append(changes, k)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", nameA, nameB)
+
+	for c := 0; c < len(changes); {
+		start := max(changes[c]-diffContext, 0)
+		end := changes[c]
+
+		// merge changes whose context overlaps into a single hunk
+		for c < len(changes) && changes[c] <= end+2*diffContext {
+			end = changes[c]
+			c++
+		}
+		end = min(end+diffContext+1, len(ops))
+
+		hunk := ops[start:end]
+		var countA, countB int
+		for _, op := range hunk {
+			if op.Kind != diffInsert {
+				countA++
+			}
+			if op.Kind != diffDelete {
+				countB++
+			}
+		}
+
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n",
+			hunkRange(hunk[0].A, countA), hunkRange(hunk[0].B, countB))
+
+		for _, op := range hunk {
+			prefix := " "
+			switch op.Kind {
+			case diffDelete:
+				prefix = "-"
+			case diffInsert:
+				prefix = "+"
+			}
+
+			buf.WriteString(prefix)
+			buf.WriteString(op.Line)
+			if !strings.HasSuffix(op.Line, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+
+	return buf.String()
+}
+
+// hunkRange formats the line range of a hunk side, following the
+// conventions of GNU diff for empty ranges.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// FileDiff is the difference between a file on disk and its freshly
+// rendered Content.
+type FileDiff struct {
+	Name     string
+	FilePath string
+	Diff     string
+}
+
+// Diff renders every prepared Content and compares it with the existing
+// file at its target path. Targets that do not exist yet are ignored, as
+// are files that only differ in line endings. Nothing is written.
+func (g *Generator) Diff() ([]FileDiff, error) {
+	var diffs []FileDiff
+	for _, content := range g.Content {
+		if content.Dirty || !stat(g.Afs, content.FilePath) {
+			continue
+		}
+
+		rendered, err := g.render(content)
+		if err != nil {
+			return nil, err
+		}
+
+		current, err := afero.ReadFile(g.Afs, content.FilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		diff := unifiedDiff(content.FilePath, content.FilePath+" (generated)", current, rendered)
+		if diff != "" {
+			diffs = append(diffs, FileDiff{
+				Name:     content.Name,
+				FilePath: content.FilePath,
+				Diff:     diff,
+			})
+		}
+	}
+	return diffs, nil
+}
@@ -0,0 +1,53 @@
+package project
+
+import (
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "one\ntwo\n",
+			b:    "one\r\ntwo\r\n",
+			want: "",
+		},
+		{
+			name: "changed line",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			b:    "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "insert into empty",
+			a:    "",
+			b:    "new\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1 @@\n+new\n",
+		},
+		{
+			name: "missing final newline",
+			a:    "x\ny\n",
+			b:    "x\ny",
+			want: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n x\n-y\n+y\n\\ No newline at end of file\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			b:    "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+			want: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unifiedDiff("a", "b", []byte(tt.a), []byte(tt.b))
+			if got != tt.want {
+				t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
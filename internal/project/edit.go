@@ -0,0 +1,178 @@
+package project
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+)
+
+// textEdit replaces src[start:end] with text. Edits are made on the source
+// text so that everything they do not touch, comments included, is kept
+// byte for byte.
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+// applyEdits applies non-overlapping edits to src. Insertions at the same
+// offset keep their order.
+func applyEdits(src []byte, edits []textEdit) []byte {
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start < edits[j].start
+	})
+
+	out := src
+	for i := len(edits) - 1; i >= 0; i-- {
+		e := edits[i]
+		out = append(append(append([]byte(nil), out[:e.start]...), e.text...), out[e.end:]...)
+	}
+	return out
+}
+
+// deleteLines returns the edit removing the whole lines spanned by node.
+func deleteLines(fset *token.FileSet, src []byte, node ast.Node) textEdit {
+	start := lineStart(src, fset.Position(node.Pos()).Offset)
+	end := lineEnd(src, fset.Position(node.End()).Offset)
+	if end < len(src) {
+		end++
+	}
+	return textEdit{start: start, end: end}
+}
+
+// nodeText returns the source of node.
+func nodeText(fset *token.FileSet, src []byte, node ast.Node) string {
+	return string(src[fset.Position(node.Pos()).Offset:fset.Position(node.End()).Offset])
+}
+
+// sameExpr reports whether node and the expression text are the same once
+// gofmt'd, so that 30 * time.Second matches 30*time.Second.
+func sameExpr(fset *token.FileSet, node ast.Node, text string) bool {
+	expr, err := parser.ParseExpr(text)
+	if err != nil {
+		return false
+	}
+
+	var a, b bytes.Buffer
+	if format.Node(&a, fset, node) != nil || format.Node(&b, token.NewFileSet(), expr) != nil {
+		return false
+	}
+	return a.String() == b.String()
+}
+
+// lineStart returns the offset of the start of the line holding offset.
+func lineStart(src []byte, offset int) int {
+	return bytes.LastIndexByte(src[:offset], '\n') + 1
+}
+
+// lineEnd returns the offset of the newline ending the line holding offset.
+func lineEnd(src []byte, offset int) int {
+	if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+		return offset + i
+	}
+	return len(src)
+}
+
+func insertAt(src []byte, offset int, text string) []byte {
+	out := make([]byte, 0, len(src)+len(text))
+	out = append(out, src[:offset]...)
+	out = append(out, text...)
+	return append(out, src[offset:]...)
+}
+
+// stringLit returns the value of expr when it is a string literal.
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
+
+// commandLiteral returns the declaration of the package variable name in
+// file and the cobra.Command literal it is initialized with.
+func commandLiteral(file *ast.File, name string) (*ast.GenDecl, *ast.CompositeLit) {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			for i, ident := range value.Names {
+				if ident.Name != name || i >= len(value.Values) {
+					continue
+				}
+
+				expr := value.Values[i]
+				if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+					expr = unary.X
+				}
+				if lit, ok := expr.(*ast.CompositeLit); ok {
+					return gen, lit
+				}
+				return gen, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// literalField returns the element of lit with the given key.
+func literalField(lit *ast.CompositeLit, key string) *ast.KeyValueExpr {
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if ident, ok := kv.Key.(*ast.Ident); ok && ident.Name == key {
+			return kv
+		}
+	}
+	return nil
+}
+
+// hasVar reports whether file declares the package variable name.
+func hasVar(file *ast.File, name string) bool {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			for _, ident := range spec.(*ast.ValueSpec).Names {
+				if ident.Name == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// addImport adds path to the imports of file unless it is imported.
+func addImport(fset *token.FileSet, src []byte, file *ast.File, path string) []byte {
+	for _, spec := range file.Imports {
+		if value, _ := stringLit(spec.Path); value == path {
+			return src
+		}
+	}
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if gen.Rparen.IsValid() {
+			return insertAt(src, lineStart(src, fset.Position(gen.Rparen).Offset), "\t"+strconv.Quote(path)+"\n")
+		}
+		return insertAt(src, fset.Position(gen.Pos()).Offset, "import "+strconv.Quote(path)+"\n")
+	}
+
+	return insertAt(src, lineEnd(src, fset.Position(file.Name.End()).Offset), "\n\nimport "+strconv.Quote(path))
+}
@@ -0,0 +1,138 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formatGoSource runs gofmt over a rendered Go source. With modulePath set,
+// imports are also regrouped the way goimports does: standard library,
+// third party, then packages of the module itself.
+func formatGoSource(src []byte, modulePath string) ([]byte, error) {
+	if modulePath != "" {
+		grouped, err := groupImports(src, modulePath)
+		if err != nil {
+			return nil, err
+		}
+		src = grouped
+	}
+	return format.Source(src)
+}
+
+// formatError names the template a Go source was rendered from and the
+// offending line of the rendered output.
+func formatError(content Content, src []byte, err error) error {
+	var list scanner.ErrorList
+	if !errors.As(err, &list) || len(list) == 0 {
+		return fmt.Errorf("format %s (template %s): %w", content.Name, content.TemplateFilePath, err)
+	}
+
+	pos := list[0].Pos
+	var line string
+	if lines := strings.Split(string(src), "\n"); pos.Line > 0 && pos.Line <= len(lines) {
+		line = strings.TrimRight(lines[pos.Line-1], "\r")
+	}
+
+	return fmt.Errorf("format %s (template %s): rendered line %d: %s\n\t%s",
+		content.Name, content.TemplateFilePath, pos.Line, list[0].Msg, line)
+}
+
+// groupImports rewrites the first import declaration of src into sorted
+// groups separated by blank lines. Declarations carrying comments are left
+// untouched so that nothing is lost.
+func groupImports(src []byte, modulePath string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var decl *ast.GenDecl
+	for _, d := range file.Decls {
+		if gen, ok := d.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			decl = gen
+			break
+		}
+	}
+	if decl == nil || len(decl.Specs) == 0 {
+		return src, nil
+	}
+
+	start, end := fset.Position(decl.Pos()).Offset, fset.Position(decl.End()).Offset
+	for _, group := range file.Comments {
+		if offset := fset.Position(group.Pos()).Offset; offset > start && offset < end {
+			return src, nil
+		}
+	}
+
+	groups := make([][]string, 3)
+	for _, spec := range decl.Specs {
+		imp := spec.(*ast.ImportSpec)
+
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		line := imp.Path.Value
+		if imp.Name != nil {
+			line = imp.Name.Name + " " + line
+		}
+
+		group := importGroup(importPath, modulePath)
+		groups[group] = append(groups[group], line)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("import (\n")
+	first := true
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		if !first {
+			buf.WriteString("\n")
+		}
+		first = false
+
+		sort.SliceStable(group, func(i, j int) bool {
+			return importPathOf(group[i]) < importPathOf(group[j])
+		})
+		for _, line := range group {
+			buf.WriteString("\t" + line + "\n")
+		}
+	}
+	buf.WriteString(")")
+
+	out := make([]byte, 0, len(src)+buf.Len())
+	out = append(out, src[:start]...)
+	out = append(out, buf.Bytes()...)
+	out = append(out, src[end:]...)
+	return out, nil
+}
+
+// importGroup classifies an import path: 0 for the standard library, 1 for
+// third party packages and 2 for packages of modulePath.
+func importGroup(importPath, modulePath string) int {
+	switch {
+	case importPath == modulePath || strings.HasPrefix(importPath, modulePath+"/"):
+		return 2
+	case !strings.Contains(strings.Split(importPath, "/")[0], "."):
+		return 0
+	default:
+		return 1
+	}
+}
+
+func importPathOf(line string) string {
+	return line[strings.Index(line, `"`):]
+}
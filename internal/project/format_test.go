@@ -0,0 +1,67 @@
+package project
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatGoSourceGroupsImports(t *testing.T) {
+	src := `package cmd
+
+import (
+	"github.com/acme/myproject/internal/config"
+	"os"
+	"github.com/spf13/cobra"
+	"fmt"
+)
+
+func init() {
+    _, _, _, _ = config.X, os.Args, cobra.Command{}, fmt.Sprint
+}
+`
+	want := `package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/acme/myproject/internal/config"
+)
+
+func init() {
+	_, _, _, _ = config.X, os.Args, cobra.Command{}, fmt.Sprint
+}
+`
+
+	got, err := formatGoSource([]byte(src), "github.com/acme/myproject")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Fatalf("unexpected output:\n%s", got)
+	}
+}
+
+func TestRenderFormatError(t *testing.T) {
+	generator := &Generator{Project: &Project{}}
+	content := Content{
+		Name:             "root",
+		FilePath:         "cmd/root.go",
+		TemplateFilePath: "tpl/root.tmpl",
+		TemplateContent:  "package cmd\n\nfunc {{ .AppName }}( {\n}\n",
+		Data:             &Project{AppName: "broken"},
+	}
+
+	_, err := generator.render(content)
+	if err == nil {
+		t.Fatal("expected a format error")
+	}
+
+	for _, want := range []string{"tpl/root.tmpl", "rendered line 3", "func broken( {"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
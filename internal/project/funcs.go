@@ -0,0 +1,203 @@
+package project
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// funcMap returns the functions available to every template, built-in or
+// user supplied:
+//
+//	camel "add-user"        addUser
+//	pascal "add-user"       AddUser
+//	kebab "addUser"         add-user
+//	snake "addUser"         add_user
+//	screaming "addUser"     ADD_USER
+//	lower, upper            strings.ToLower, strings.ToUpper
+//	plural "command"        commands
+//	quote "a\"b"            "a\"b" (a Go string literal)
+//	squote "it's"           'it''s' (a YAML single-quoted string)
+//	escape "a\"b"           a\"b (quote without the surrounding quotes)
+//	now                     the current time.Time
+//	year                    the copyright year, the year setting or the current year
+//	env "HOME"              the value of an environment variable
+//	default "x" .Value      .Value, or "x" when .Value is empty
+//	indent 4 .Text          .Text with every line indented by 4 spaces
+//	nindent 4 .Text         a newline followed by indent 4 .Text
+//	join ", " .List         the elements of .List separated by ", "
+func funcMap() template.FuncMap {
+	return template.FuncMap{
+		"camel":     camelCase,
+		"pascal":    pascalCase,
+		"kebab":     func(s string) string { return joinWords(s, "-", strings.ToLower) },
+		"snake":     func(s string) string { return joinWords(s, "_", strings.ToLower) },
+		"screaming": func(s string) string { return joinWords(s, "_", strings.ToUpper) },
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+		"plural":    plural,
+		"quote":     strconv.Quote,
+		"squote":    singleQuote,
+		"escape":    escape,
+		"now":       time.Now,
+		"year":      year,
+		"env":       os.Getenv,
+		"default":   defaultValue,
+		"indent":    indent,
+		"nindent":   func(n int, s string) string { return "\n" + indent(n, s) },
+		"join":      join,
+	}
+}
+
+// splitWords splits an identifier into its words, on separators as well as
+// on case changes: "HTTPServer-config" gives HTTP, Server and config.
+func splitWords(s string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if r == '-' || r == '_' || r == '.' || r == '/' || unicode.IsSpace(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+func joinWords(s, sep string, convert func(string) string) string {
+	words := splitWords(s)
+	for i, word := range words {
+		words[i] = convert(word)
+	}
+	return strings.Join(words, sep)
+}
+
+func capitalize(s string) string {
+	runes := []rune(strings.ToLower(s))
+	if len(runes) == 0 {
+		return s
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func pascalCase(s string) string {
+	return joinWords(s, "", capitalize)
+}
+
+func camelCase(s string) string {
+	words := splitWords(s)
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+		} else {
+			words[i] = capitalize(word)
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// plural returns the English plural of a noun for the regular cases.
+func plural(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case s == "":
+		return s
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return s + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return s[:len(s)-1] + "ies"
+	default:
+		return s + "s"
+	}
+}
+
+// singleQuote returns s as a YAML single-quoted scalar, where a quote is
+// escaped by doubling it and backslashes are literal.
+func singleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func escape(s string) string {
+	quoted := strconv.Quote(s)
+	return quoted[1 : len(quoted)-1]
+}
+
+func year() string {
+	if y := viper.GetString("year"); y != "" {
+		return y
+	}
+	return time.Now().Format("2006")
+}
+
+func defaultValue(def, value any) any {
+	if value == nil {
+		return def
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if v.Len() == 0 {
+			return def
+		}
+	case reflect.Bool:
+		if !v.Bool() {
+			return def
+		}
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return def
+		}
+	default:
+		if v.IsZero() {
+			return def
+		}
+	}
+	return value
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func join(sep string, list any) (string, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: expected a list, got %T", list)
+	}
+
+	parts := make([]string, v.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
@@ -0,0 +1,62 @@
+package project
+
+import (
+	"github.com/spf13/viper"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestFuncMap(t *testing.T) {
+	viper.Set("year", "2021")
+	t.Setenv("COBRA_CLI_TEST", "from env")
+	defer viper.Reset()
+
+	data := map[string]any{
+		"Name":  "addUser",
+		"Empty": "",
+		"List":  []string{"a", "b"},
+		"Text":  "one\ntwo",
+	}
+
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{`{{ camel "add-user" }}`, "addUser"},
+		{`{{ pascal "add_user" }}`, "AddUser"},
+		{`{{ kebab .Name }}`, "add-user"},
+		{`{{ snake "HTTPServer" }}`, "http_server"},
+		{`{{ screaming .Name }}`, "ADD_USER"},
+		{`{{ pascal "v2Config" }}`, "V2Config"},
+		{`{{ plural "command" }} {{ plural "box" }} {{ plural "policy" }} {{ plural "key" }}`, "commands boxes policies keys"},
+		{`{{ quote "a\"b" }}`, `"a\"b"`},
+		{`{{ squote "it's" }}`, `'it''s'`},
+		{`{{ squote "C:\\dir" }}`, `'C:\dir'`},
+		{`{{ escape "a\"b\n" }}`, `a\"b\n`},
+		{`{{ year }}`, "2021"},
+		{`{{ env "COBRA_CLI_TEST" }}`, "from env"},
+		{`{{ default "fallback" .Empty }} {{ default "fallback" .Name }}`, "fallback addUser"},
+		{`{{ indent 2 .Text }}`, "  one\n  two"},
+		{`x:{{ nindent 2 .Text }}`, "x:\n  one\n  two"},
+		{`{{ join ", " .List }}`, "a, b"},
+		{`{{ if now }}ok{{ end }}`, "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			tmpl, err := template.New("test").Funcs(funcMap()).Parse(tt.tmpl)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var sb strings.Builder
+			if err := tmpl.Execute(&sb, data); err != nil {
+				t.Fatal(err)
+			}
+			if sb.String() != tt.want {
+				t.Fatalf("got %q, want %q", sb.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,694 @@
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/afero"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"gopkg.in/yaml.v3"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TreeSpec declares a whole command tree for `cobra-cli generate`. Groups
+// are the groups of the root command.
+type TreeSpec struct {
+	Groups   []Group       `yaml:"groups,omitempty" json:"groups,omitempty"`
+	Commands []SpecCommand `yaml:"commands" json:"commands"`
+}
+
+// SpecCommand is a command of a TreeSpec. Parent is the name of another
+// command of the spec, the variable of a command of the cmd package, or
+// empty for the root command.
+type SpecCommand struct {
+	Name        string `yaml:"name" json:"name"`
+	Parent      string `yaml:"parent,omitempty" json:"parent,omitempty"`
+	CommandSpec `yaml:",inline"`
+}
+
+var commandName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+
+// LoadSpec reads the TreeSpec at path, a JSON file when its extension is
+// .json and YAML otherwise. Unknown keys are an error, so that a misspelled
+// one is not silently ignored.
+func LoadSpec(afs afero.Fs, path string) (*TreeSpec, error) {
+	data, err := afero.ReadFile(afs, path)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &TreeSpec{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(spec)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(spec)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("spec %s: %w", path, err)
+	}
+
+	if err := spec.validate(); err != nil {
+		return nil, fmt.Errorf("spec %s: %w", path, err)
+	}
+	return spec, nil
+}
+
+func (s *TreeSpec) validate() error {
+	if len(s.Commands) == 0 {
+		return errors.New("no commands")
+	}
+
+	seen := map[string]bool{}
+	for i := range s.Commands {
+		command := &s.Commands[i]
+		if !commandName.MatchString(command.Name) {
+			return fmt.Errorf("command %d: invalid name %q", i, command.Name)
+		}
+		if seen[command.Name] {
+			return fmt.Errorf("command %s is declared twice", command.Name)
+		}
+		seen[command.Name] = true
+
+		for j := range command.Flags {
+			if command.Flags[j].Type == "" {
+				command.Flags[j].Type = "string"
+			}
+		}
+		if err := command.Validate(); err != nil {
+			return fmt.Errorf("command %s: %w", command.Name, err)
+		}
+	}
+
+	for _, command := range s.Commands {
+		if command.Group == "" {
+			continue
+		}
+
+		groups := s.Groups
+		if parent := s.command(command.Parent); parent != nil {
+			groups = parent.Groups
+		} else if !isRoot(command.Parent) {
+			// groups of commands outside the spec are not known
+			continue
+		}
+		if !slices.ContainsFunc(groups, func(group Group) bool { return group.ID == command.Group }) {
+			return fmt.Errorf("command %s: group %s is not declared by its parent", command.Name, command.Group)
+		}
+	}
+	return nil
+}
+
+func isRoot(parent string) bool {
+	return parent == "" || parent == "root" || parent == "rootCmd"
+}
+
+// command returns the command of the spec called name.
+func (s *TreeSpec) command(name string) *SpecCommand {
+	for i := range s.Commands {
+		if s.Commands[i].Name == name {
+			return &s.Commands[i]
+		}
+	}
+	return nil
+}
+
+// ordered returns the commands of the spec, every parent before its
+// children.
+func (s *TreeSpec) ordered() ([]SpecCommand, error) {
+	var (
+		ordered []SpecCommand
+		done    = map[string]bool{}
+		visit   func(command SpecCommand, path []string) error
+	)
+
+	visit = func(command SpecCommand, path []string) error {
+		if done[command.Name] {
+			return nil
+		}
+		if slices.Contains(path, command.Name) {
+			return fmt.Errorf("commands form a cycle: %s", strings.Join(append(path, command.Name), " -> "))
+		}
+
+		if parent := s.command(command.Parent); parent != nil {
+			if err := visit(*parent, append(path, command.Name)); err != nil {
+				return err
+			}
+		}
+
+		done[command.Name] = true
+		ordered = append(ordered, command)
+		return nil
+	}
+
+	for _, command := range s.Commands {
+		if err := visit(command, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// parentVar returns the variable of the parent of command.
+func (s *TreeSpec) parentVar(command SpecCommand, pkg *commandPackage) (string, error) {
+	switch {
+	case isRoot(command.Parent):
+		return "rootCmd", nil
+	case s.command(command.Parent) != nil:
+		return validateCmdName([]string{command.Parent}) + "Cmd", nil
+	}
+
+	for _, name := range []string{command.Parent, validateCmdName([]string{command.Parent}) + "Cmd"} {
+		if _, ok := pkg.vars[name]; ok {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("command %s: parent %s is neither in the spec nor declared in package cmd", command.Name, command.Parent)
+}
+
+// fileUpdate is the new content of an existing file.
+type fileUpdate struct {
+	FilePath string
+	Data     []byte
+}
+
+// PrepareSpecModels collects the content of every command of spec that is
+// not declared in the cmd package of the project holding dir, and updates
+// the metadata of the ones that are: their cobra.Command fields, flags and
+// groups. The bodies of their Run functions are left alone.
+func (g *Generator) PrepareSpecModels(spec *TreeSpec, dir string) error {
+	rootGo, err := g.locateRootGo(dir)
+	if err != nil {
+		return err
+	}
+	g.Project.AbsolutePath = filepath.Dir(rootGo)
+
+	tmpl, err := g.commandTemplate(rootGo)
+	if err != nil {
+		return err
+	}
+
+	pkg, err := parseCommandPackage(g.Afs, g.Project.AbsolutePath)
+	if err != nil {
+		return err
+	}
+
+	if len(spec.Groups) > 0 {
+		if err := g.updateFile(pkg.vars["rootCmd"], func(src []byte, filename string) ([]byte, error) {
+			return addGroups(src, filename, "rootCmd", spec.Groups)
+		}); err != nil {
+			return err
+		}
+	}
+
+	commands, err := spec.ordered()
+	if err != nil {
+		return err
+	}
+
+	for _, command := range commands {
+		parent, err := spec.parentVar(command, pkg)
+		if err != nil {
+			return err
+		}
+
+		cmdName := validateCmdName([]string{command.Name})
+		if declared, ok := pkg.vars[cmdName+"Cmd"]; ok {
+			if err := g.updateFile(declared, func(src []byte, filename string) ([]byte, error) {
+				return updateCommand(src, filename, cmdName, command.CommandSpec)
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		g.Content = append(g.Content, tmpl.content(filepath.Join(g.Project.AbsolutePath, command.Name+".go"), Command{
+			CmdName:          cmdName,
+			CmdParent:        parent,
+			ExtractedLicense: tmpl.license,
+			Registration:     g.Registration,
+			Spec:             command.CommandSpec,
+			Project:          g.Project,
+		}))
+	}
+	return nil
+}
+
+// GenerateFromSpec prepares the models of spec and writes them, see
+// PrepareSpecModels.
+func (g *Generator) GenerateFromSpec(spec *TreeSpec, dir string) error {
+	if err := g.PrepareSpecModels(spec, dir); err != nil {
+		return err
+	}
+	return g.renderTemplate()
+}
+
+// Updated returns the existing files whose content changes.
+func (g *Generator) Updated() []string {
+	files := make([]string, 0, len(g.updates))
+	for _, update := range g.updates {
+		files = append(files, update.FilePath)
+	}
+	return files
+}
+
+// updateFile applies change to the pending content of filePath, recording
+// it as updated when it changes.
+func (g *Generator) updateFile(filePath string, change func(src []byte, filename string) ([]byte, error)) error {
+	i := slices.IndexFunc(g.updates, func(update fileUpdate) bool { return update.FilePath == filePath })
+
+	var src []byte
+	if i >= 0 {
+		src = g.updates[i].Data
+	} else {
+		var err error
+		if src, err = afero.ReadFile(g.Afs, filePath); err != nil {
+			return err
+		}
+	}
+
+	data, err := change(src, filePath)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case i >= 0:
+		g.updates[i].Data = data
+	case !bytes.Equal(data, src):
+		g.updates = append(g.updates, fileUpdate{FilePath: filePath, Data: data})
+	}
+	return nil
+}
+
+// updateCommand rewrites the metadata of the command cmdName declared in
+// src to match spec: the fields of its cobra.Command literal, its flags and
+// the groups of its children. Only what spec has is set: fields and flags
+// are added or updated, never removed.
+func updateCommand(src []byte, filename, cmdName string, spec CommandSpec) ([]byte, error) {
+	src, err := updateCommandFields(src, filename, cmdName+"Cmd", spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if src, err = addGroups(src, filename, cmdName+"Cmd", spec.Groups); err != nil {
+		return nil, err
+	}
+
+	command := Command{CmdName: cmdName}
+	for _, flag := range spec.Flags {
+		if src, err = updateFlag(src, filename, command, flag); err != nil {
+			return nil, err
+		}
+	}
+	return src, nil
+}
+
+// updateCommandFields sets the fields of the cobra.Command literal of
+// cmdVar that a spec describes, keeping every other field as it is: a field
+// the spec omits is never removed.
+func updateCommandFields(src []byte, filename, cmdVar string, spec CommandSpec) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	_, lit := commandLiteral(file, cmdVar)
+	if lit == nil {
+		return nil, fmt.Errorf("%s: %s is not declared as a cobra.Command literal", filename, cmdVar)
+	}
+
+	var aliases []string
+	for _, alias := range spec.Aliases {
+		aliases = append(aliases, strconv.Quote(alias))
+	}
+
+	var long, hidden string
+	if spec.Long != "" {
+		long = spec.LongLiteral()
+	}
+	if spec.Hidden {
+		hidden = "true"
+	}
+
+	fields := []struct {
+		key   string
+		value string
+	}{
+		{key: "Aliases", value: sliceLiteral("string", aliases)},
+		{key: "GroupID", value: quoteNonEmpty(spec.Group)},
+		{key: "Short", value: quoteNonEmpty(spec.Short)},
+		{key: "Long", value: long},
+		{key: "Args", value: spec.ArgsValidator()},
+		{key: "Hidden", value: hidden},
+		{key: "Deprecated", value: quoteNonEmpty(spec.Deprecated)},
+	}
+
+	// a new field goes after the field before it in the order of the
+	// templates, or else before the Run functions
+	insert := lineStart(src, fset.Position(lit.Rbrace).Offset)
+	if use := literalField(lit, "Use"); use != nil {
+		insert = lineEnd(src, fset.Position(use.End()).Offset) + 1
+	} else {
+		for _, elt := range lit.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if ident, ok := kv.Key.(*ast.Ident); ok && strings.Contains(ident.Name, "Run") {
+					insert = lineStart(src, fset.Position(kv.Pos()).Offset)
+					break
+				}
+			}
+		}
+	}
+
+	var edits []textEdit
+	for _, field := range fields {
+		kv := literalField(lit, field.key)
+		if kv != nil {
+			insert = lineEnd(src, fset.Position(kv.End()).Offset) + 1
+		}
+
+		switch {
+		case field.value == "":
+			// omitted by the spec
+		case kv != nil && !sameExpr(fset, kv.Value, field.value):
+			edits = append(edits, textEdit{
+				start: fset.Position(kv.Value.Pos()).Offset,
+				end:   fset.Position(kv.Value.End()).Offset,
+				text:  field.value,
+			})
+		case kv == nil && field.value != "":
+			edits = append(edits, textEdit{start: insert, end: insert, text: "\t" + field.key + ": " + field.value + ",\n"})
+		}
+	}
+
+	if len(edits) == 0 {
+		return src, nil
+	}
+
+	formatted, err := format.Source(applyEdits(src, edits))
+	if err != nil {
+		return nil, fmt.Errorf("update %s in %s: %w", cmdVar, filename, err)
+	}
+	return formatted, nil
+}
+
+func quoteNonEmpty(s string) string {
+	if s == "" {
+		return ""
+	}
+	return strconv.Quote(s)
+}
+
+func sliceLiteral(elem string, items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+	return "[]" + elem + "{" + strings.Join(items, ", ") + "}"
+}
+
+// flagCall returns the name of the flag declared by stmt when it is a call
+// such as cmdVar.Flags().StringVar(&v, "name", ...), and its call.
+func flagCall(stmt ast.Stmt, cmdVar string) (string, *ast.CallExpr) {
+	expr, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return "", nil
+	}
+	call, ok := expr.X.(*ast.CallExpr)
+	if !ok {
+		return "", nil
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", nil
+	}
+	set, ok := sel.X.(*ast.CallExpr)
+	if !ok {
+		return "", nil
+	}
+	setSel, ok := set.Fun.(*ast.SelectorExpr)
+	if !ok || (setSel.Sel.Name != "Flags" && setSel.Sel.Name != "PersistentFlags") {
+		return "", nil
+	}
+	if x, ok := setSel.X.(*ast.Ident); !ok || x.Name != cmdVar {
+		return "", nil
+	}
+
+	arg := 0
+	if strings.HasSuffix(sel.Sel.Name, "Var") || strings.HasSuffix(sel.Sel.Name, "VarP") {
+		arg = 1
+	}
+	if len(call.Args) <= arg {
+		return "", nil
+	}
+	name, _ := stringLit(call.Args[arg])
+	return name, call
+}
+
+// requiredCall reports whether node marks the flag name of cmdVar as
+// required, any flag when name is "".
+func requiredCall(node ast.Node, cmdVar, name string) bool {
+	return flagMethodCall(node, cmdVar, name, "MarkFlagRequired", "MarkPersistentFlagRequired")
+}
+
+// flagMethodCall reports whether node calls one of methods of cmdVar with
+// the flag name, any flag when name is "", as first argument.
+func flagMethodCall(node ast.Node, cmdVar, name string, methods ...string) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || found {
+			return !found
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || !slices.Contains(methods, sel.Sel.Name) {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); ok && x.Name == cmdVar && len(call.Args) > 0 {
+			if value, _ := stringLit(call.Args[0]); name == "" || value == name {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// updateFlag declares flag on the command in src, or updates the call
+// declaring it, marks it as required or not and stubs the completion of a
+// new flag.
+func updateFlag(src []byte, filename string, command Command, flag Flag) ([]byte, error) {
+	cmdVar := command.CmdName + "Cmd"
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		existing *ast.CallExpr
+		stmts    []ast.Stmt
+	)
+	for _, decl := range file.Decls {
+		if fn := initFunc(decl); fn != nil {
+			stmts = append(stmts, fn.Body.List...)
+		}
+	}
+	var bound []string
+	for _, stmt := range stmts {
+		name, call := flagCall(stmt, cmdVar)
+		if call == nil {
+			continue
+		}
+		if name == flag.Name {
+			existing = call
+		}
+		if unary, ok := call.Args[0].(*ast.UnaryExpr); ok {
+			if ident, ok := unary.X.(*ast.Ident); ok {
+				bound = append(bound, ident.Name)
+			}
+		}
+	}
+
+	switch {
+	case existing == nil:
+		variable := command.FlagVar(flag)
+		switch block, _ := commandLiteral(file, cmdVar); {
+		case hasVar(file, variable):
+		case varBlock(file, bound) != nil:
+			// next to the variables of the other flags
+			rparen := varBlock(file, bound).Rparen
+			src = insertAt(src, lineStart(src, fset.Position(rparen).Offset), "\t"+variable+" "+flag.GoType()+"\n")
+		case block != nil:
+			src = insertAt(src, lineStart(src, fset.Position(block.Pos()).Offset), "var "+variable+" "+flag.GoType()+"\n\n")
+		default:
+			return nil, fmt.Errorf("%s: %s is not declared", filename, cmdVar)
+		}
+
+		if flag.Type == "duration" {
+			if file, err = parser.ParseFile(fset, filename, src, parser.ImportsOnly); err != nil {
+				return nil, err
+			}
+			src = addImport(fset, src, file, "time")
+		}
+
+		stmt := fmt.Sprintf("%s.%s().%s(&%s, %q, %s, %q)", cmdVar, flag.FlagSet(), flag.Func(), variable, flag.Name, flag.Value(), flag.Usage)
+		if src, err = insertIntoInit(src, filename, stmt, func(stmt ast.Stmt) bool {
+			_, call := flagCall(stmt, cmdVar)
+			return call != nil
+		}); err != nil {
+			return nil, err
+		}
+	case strings.HasSuffix(existing.Fun.(*ast.SelectorExpr).Sel.Name, "Var") && len(existing.Args) == 4:
+		// keep the variable the flag is bound to, update the rest
+		variable := nodeText(fset, src, existing.Args[0])
+		call := fmt.Sprintf("%s.%s().%s(%s, %q, %s, %q)", cmdVar, flag.FlagSet(), flag.Func(), variable, flag.Name, flag.Value(), flag.Usage)
+		if !sameExpr(fset, existing, call) {
+			src = applyEdits(src, []textEdit{{
+				start: fset.Position(existing.Pos()).Offset,
+				end:   fset.Position(existing.End()).Offset,
+				text:  call,
+			}})
+		}
+	}
+
+	if src, err = updateRequired(src, filename, cmdVar, flag); err != nil {
+		return nil, err
+	}
+
+	if existing != nil || flag.Type == "bool" {
+		return src, nil
+	}
+	stmt := fmt.Sprintf(`cobra.CheckErr(%s.RegisterFlagCompletionFunc(%q, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// TODO: complete the values of --%s
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}))`, cmdVar, flag.Name, flag.Name)
+	return insertIntoInit(src, filename, stmt, func(stmt ast.Stmt) bool {
+		_, call := flagCall(stmt, cmdVar)
+		return call != nil || flagMethodCall(stmt, cmdVar, "", "MarkFlagRequired", "MarkPersistentFlagRequired", "RegisterFlagCompletionFunc")
+	})
+}
+
+// varBlock returns the parenthesized var declaration of file declaring one
+// of names.
+func varBlock(file *ast.File, names []string) *ast.GenDecl {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR || !gen.Rparen.IsValid() {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			for _, ident := range spec.(*ast.ValueSpec).Names {
+				if slices.Contains(names, ident.Name) {
+					return gen
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// updateRequired adds or removes the call marking flag as required.
+func updateRequired(src []byte, filename, cmdVar string, flag Flag) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var marked ast.Stmt
+	for _, decl := range file.Decls {
+		if fn := initFunc(decl); fn != nil {
+			for _, stmt := range fn.Body.List {
+				if requiredCall(stmt, cmdVar, flag.Name) {
+					marked = stmt
+				}
+			}
+		}
+	}
+
+	switch {
+	case flag.Required && marked == nil:
+		stmt := fmt.Sprintf("cobra.CheckErr(%s.%s(%q))", cmdVar, flag.MarkRequired(), flag.Name)
+		return insertIntoInit(src, filename, stmt, func(stmt ast.Stmt) bool {
+			_, call := flagCall(stmt, cmdVar)
+			return call != nil || requiredCall(stmt, cmdVar, "")
+		})
+	case !flag.Required && marked != nil:
+		formatted, err := format.Source(applyEdits(src, []textEdit{deleteLines(fset, src, marked)}))
+		if err != nil {
+			return nil, fmt.Errorf("update %s in %s: %w", cmdVar, filename, err)
+		}
+		return formatted, nil
+	}
+	return src, nil
+}
+
+// addGroups adds the groups missing from the init() of src to cmdVar.
+func addGroups(src []byte, filename, cmdVar string, groups []Group) ([]byte, error) {
+	for _, group := range groups {
+		added, err := initHas(src, filename, func(stmt ast.Stmt) bool {
+			return slices.ContainsFunc(methodCall(stmt, cmdVar, "AddGroup"), func(arg ast.Expr) bool {
+				return groupID(arg) == group.ID
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			continue
+		}
+
+		stmt := fmt.Sprintf("%s.AddGroup(&cobra.Group{ID: %q, Title: %q})", cmdVar, group.ID, group.Title)
+		if src, err = insertIntoInit(src, filename, stmt, func(stmt ast.Stmt) bool {
+			return methodCall(stmt, cmdVar, "AddGroup") != nil || methodCall(stmt, cmdVar, "AddCommand") != nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return src, nil
+}
+
+// groupID returns the ID of a &cobra.Group{...} literal.
+func groupID(expr ast.Expr) string {
+	if unary, ok := expr.(*ast.UnaryExpr); ok {
+		expr = unary.X
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return ""
+	}
+	if kv := literalField(lit, "ID"); kv != nil {
+		id, _ := stringLit(kv.Value)
+		return id
+	}
+	return ""
+}
+
+// stageUpdates writes the updated files into mem and returns their paths.
+func (g *Generator) stageUpdates(mem afero.Fs) ([]string, error) {
+	files := make([]string, 0, len(g.updates))
+	for _, update := range g.updates {
+		if err := mem.MkdirAll(filepath.Dir(update.FilePath), 0751); err != nil {
+			return nil, err
+		}
+		if err := writeFileContent(mem, update.FilePath, update.Data); err != nil {
+			return nil, err
+		}
+		files = append(files, update.FilePath)
+	}
+	return files, nil
+}
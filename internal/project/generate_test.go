@@ -0,0 +1,153 @@
+package project
+
+import (
+	"bytes"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSpec(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		spec string
+		err  string
+	}{
+		{name: "yaml", file: "spec.yaml", spec: "commands:\n  - name: serve\n    flags:\n      - name: port\n"},
+		{name: "json", file: "spec.json", spec: `{"commands": [{"name": "serve", "flags": [{"name": "port", "type": "int"}]}]}`},
+		{name: "no commands", file: "spec.yaml", spec: "groups: []\n", err: "no commands"},
+		{name: "duplicate", file: "spec.yaml", spec: "commands:\n  - name: serve\n  - name: serve\n", err: "declared twice"},
+		{name: "invalid name", file: "spec.yaml", spec: "commands:\n  - name: 1serve\n", err: "invalid name"},
+		{name: "invalid flag", file: "spec.yaml", spec: "commands:\n  - name: serve\n    flags:\n      - name: port\n        type: uint\n", err: "unknown type"},
+		{name: "undeclared group", file: "spec.yaml", spec: "commands:\n  - name: serve\n    group: run\n", err: "group run is not declared"},
+		{name: "unknown key", file: "spec.yaml", spec: "commands:\n  - name: serve\n    persistentFlags:\n      - name: port\n", err: "field persistentFlags not found"},
+		{name: "unknown flag key", file: "spec.json", spec: `{"commands": [{"name": "serve", "flags": [{"name": "port", "shorthand": "p"}]}]}`, err: `unknown field "shorthand"`},
+		{name: "empty", file: "spec.yaml", spec: "", err: "no commands"},
+		{name: "cycle", file: "spec.yaml", spec: "commands:\n  - name: a\n    parent: b\n  - name: b\n    parent: a\n", err: "cycle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if err := afero.WriteFile(fs, tt.file, []byte(tt.spec), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			spec, err := LoadSpec(fs, tt.file)
+			if err == nil {
+				_, err = spec.ordered()
+			}
+			switch {
+			case tt.err == "" && err != nil:
+				t.Fatal(err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Fatalf("got error %v, want %q", err, tt.err)
+			case tt.err == "" && spec.Commands[0].Flags[0].Type == "":
+				t.Fatal("the flag type was not defaulted")
+			}
+		})
+	}
+}
+
+func TestGenerateFromSpec(t *testing.T) {
+	viper.Set("license", "none")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	cmdDir := createTestProject(t, fs)
+
+	generate(t, fs, cmdDir, `
+groups:
+  - id: manage
+    title: Management Commands
+commands:
+  - name: set
+    parent: config
+    args: exact:2
+    flags:
+      - name: global
+        type: bool
+  - name: config
+    group: manage
+    short: Manage the configuration
+`)
+
+	assertRegistered(t, fs, filepath.Join(cmdDir, "root.go"), `rootCmd.AddGroup(&cobra.Group{ID: "manage", Title: "Management Commands"})`)
+	assertRegistered(t, fs, filepath.Join(cmdDir, "config.go"), "rootCmd.AddCommand(configCmd)")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "set.go"), "configCmd.AddCommand(setCmd)")
+
+	setGo := filepath.Join(cmdDir, "set.go")
+	edit(t, fs, setGo, `cmd.Println("set called")`, `cmd.Println("edited")`)
+
+	spec := `
+commands:
+  - name: set
+    parent: configCmd
+    short: Set a value
+    aliases: [s]
+    args: range:1:2
+    flags:
+      - name: global
+        type: bool
+        usage: write the global configuration
+      - name: timeout
+        type: duration
+        default: 30s
+        required: true
+`
+	generate(t, fs, cmdDir, spec)
+	assertFileMatchesGolden(t, fs, setGo, "testdata/generate_update.golden")
+
+	// a spec already applied changes nothing
+	before, err := afero.ReadFile(fs, setGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated := generate(t, fs, cmdDir, spec); len(updated) > 0 {
+		t.Fatalf("got updates %v, want none", updated)
+	}
+	after, err := afero.ReadFile(fs, setGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Fatalf("set.go changed:\n%s", after)
+	}
+
+	// fields and flags omitted from the spec are left as they are
+	if updated := generate(t, fs, cmdDir, "commands:\n  - name: set\n    parent: configCmd\n"); len(updated) > 0 {
+		t.Fatalf("got updates %v, want none", updated)
+	}
+	assertFileMatchesGolden(t, fs, setGo, "testdata/generate_update.golden")
+}
+
+// generate applies the spec to the project of cmdDir and returns the
+// updated files.
+func generate(t *testing.T, fs afero.Fs, cmdDir, text string) []string {
+	t.Helper()
+
+	specPath := filepath.Join(filepath.Dir(cmdDir), "spec.yaml")
+	if err := afero.WriteFile(fs, specPath, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := LoadSpec(fs, specPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := NewProject(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.GenerateFromSpec(spec, cmdDir); err != nil {
+		t.Fatal(err)
+	}
+	return generator.Updated()
+}
@@ -0,0 +1,292 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/spf13/afero"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	HeaderOK       = "ok"
+	HeaderMissing  = "missing"
+	HeaderMismatch = "mismatch"
+	HeaderUpdated  = "updated"
+)
+
+// HeaderResult describes the license header of a single file of a project.
+// Body is the content of the file to write, with its header added or its
+// copyright updated, nil when the file is left as is.
+type HeaderResult struct {
+	FilePath string
+	Status   string
+	Reason   string
+	Body     []byte
+}
+
+// copyrightYears matches the years of the copyright lines cobra-cli writes,
+// a single year or a range.
+var copyrightYears = regexp.MustCompile(`(Copyright © )(\d{4})(?:-(\d{4}))?`)
+
+// headerComment returns the license header of file: the first /* */
+// comment before its package clause, whatever the package. It returns nil
+// when the file has none.
+func headerComment(file *ast.File) *ast.Comment {
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if strings.HasPrefix(comment.Text, "/*") {
+				return comment
+			}
+		}
+	}
+	return nil
+}
+
+// lineHeader returns the license header of file written as // comments: the
+// first comment group before its package clause mentioning a copyright or a
+// license. It returns nil when the file has none. Only used when file has
+// no headerComment.
+func lineHeader(file *ast.File) *ast.CommentGroup {
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		text := strings.ToLower(group.Text())
+		if strings.Contains(text, "copyright") || strings.Contains(text, "license") {
+			return group
+		}
+	}
+	return nil
+}
+
+// commentText returns the text of the /* */ comment, without its markers.
+func commentText(comment *ast.Comment) string {
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(comment.Text, "/*"), "*/"))
+}
+
+// licenseComment returns the license header of the files of a project
+// licensed under legal, as the templates render it.
+func licenseComment(legal *License) string {
+	return fmt.Sprintf("/*\n%s\n%s\n*/\n\n", legal.Copyright, legal.Header)
+}
+
+// CheckHeaders reports whether every Go file of the module holding dir
+// starts with the license header of the project. Files missing it are
+// returned with the header added as their Body; files with another header,
+// a // one included, are reported as mismatching and never rewritten. Generated files, vendor
+// and testdata directories and nested modules are skipped.
+//
+// The license is the one of headerLicense.
+func (g *Generator) CheckHeaders(dir string) ([]HeaderResult, error) {
+	root, legal, err := g.headerLicense(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []HeaderResult
+	err = g.walkGoFiles(root, func(filePath string, src []byte, file *ast.File) {
+		result := HeaderResult{FilePath: filePath, Status: HeaderOK}
+		comment := headerComment(file)
+		switch {
+		case comment == nil && lineHeader(file) != nil:
+			result.Status, result.Reason = HeaderMismatch, "header written as // comments"
+		case comment == nil:
+			result.Status = HeaderMissing
+			result.Body = append([]byte(licenseComment(legal)), src...)
+		default:
+			result.Reason = mismatchingHeader(commentText(comment), legal)
+			if result.Reason != "" {
+				result.Status = HeaderMismatch
+			}
+		}
+		results = append(results, result)
+	})
+	return results, err
+}
+
+// mismatchingHeader returns why the text of a header comment is not the one
+// of legal, "" when it is. The copyright line is only required to be there:
+// its years and holders vary from file to file.
+func mismatchingHeader(text string, legal *License) string {
+	copyright, header, _ := strings.Cut(text, "\n")
+	if !strings.HasPrefix(copyright, "Copyright") {
+		return "no copyright line"
+	}
+	if strings.Join(strings.Fields(header), " ") != strings.Join(strings.Fields(legal.Header), " ") {
+		return fmt.Sprintf("not the %s header", legal.Name)
+	}
+	return ""
+}
+
+// UpdateCopyright extends the copyright years of the license headers of the
+// module holding dir, and of its LICENSE, up to year: 2021 becomes
+// 2021-2026 and 2021-2024 becomes 2021-2026. Only the copyright lines
+// cobra-cli writes are updated, so that those quoted by the license texts
+// are left as is.
+func (g *Generator) UpdateCopyright(dir, year string) ([]HeaderResult, error) {
+	if !regexp.MustCompile(`^\d{4}$`).MatchString(year) {
+		return nil, fmt.Errorf("invalid year %q", year)
+	}
+
+	root, _, err := g.headerLicense(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []HeaderResult
+	update := func(filePath string, src []byte, start, end int) {
+		region := copyrightYears.ReplaceAllFunc(src[start:end], func(match []byte) []byte {
+			return extendCopyright(match, year)
+		})
+		if bytes.Equal(region, src[start:end]) {
+			results = append(results, HeaderResult{FilePath: filePath, Status: HeaderOK})
+			return
+		}
+
+		body := append(append(append([]byte{}, src[:start]...), region...), src[end:]...)
+		results = append(results, HeaderResult{FilePath: filePath, Status: HeaderUpdated, Body: body})
+	}
+
+	license := filepath.Join(root, "LICENSE")
+	if src, err := afero.ReadFile(g.Afs, license); err == nil && copyrightYears.Match(src) {
+		update(license, src, 0, len(src))
+	}
+
+	err = g.walkGoFiles(root, func(filePath string, src []byte, file *ast.File) {
+		comment := headerComment(file)
+		if comment == nil || !copyrightYears.MatchString(comment.Text) {
+			return
+		}
+		// the file set of a file parsed alone has a base of 1
+		update(filePath, src, int(comment.Pos())-1, int(comment.End())-1)
+	})
+	return results, err
+}
+
+// extendCopyright returns the copyright years of match extended up to
+// year.
+func extendCopyright(match []byte, year string) []byte {
+	parts := copyrightYears.FindSubmatch(match)
+	first, last := string(parts[2]), string(parts[3])
+	if last == "" {
+		last = first
+	}
+	if last >= year {
+		return match
+	}
+	if first == year {
+		return []byte(string(parts[1]) + year)
+	}
+	return []byte(string(parts[1]) + first + "-" + year)
+}
+
+// WriteHeaders writes the body of every result having one.
+func (g *Generator) WriteHeaders(results []HeaderResult) error {
+	for _, result := range results {
+		if result.Body == nil {
+			continue
+		}
+		info, err := g.Afs.Stat(result.FilePath)
+		if err != nil {
+			return err
+		}
+		if err := afero.WriteFile(g.Afs, result.FilePath, result.Body, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// headerLicense returns the root of the module holding dir and the license
+// of the project: the one recorded in its manifest, or else the one of the
+// config, or else the one identified from its LICENSE file.
+func (g *Generator) headerLicense(dir string) (string, *License, error) {
+	root, err := moduleRoot(g.Afs, dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	legal := g.Project.Legal
+	if err := g.loadProjectManifest(dir); err != nil {
+		return "", nil, err
+	}
+	if g.Manifest != nil {
+		legal = g.manifestProject().Legal
+	}
+	if legal.Code == "" || legal.Code == "none" {
+		match, err := g.DetectLicense(dir)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", nil, err
+		}
+		if match != nil && match.Confident() {
+			detected := *match.License
+			if match.Copyright != "" {
+				detected.Copyright = match.Copyright
+			}
+			legal = &detected
+		}
+	}
+
+	if legal.Code == "" || legal.Code == "none" {
+		return "", nil, fmt.Errorf("the project has no license, set one with --license")
+	}
+	return root, legal, nil
+}
+
+// moduleRoot returns the directory of the go.mod of the module holding dir.
+func moduleRoot(afs afero.Fs, dir string) (string, error) {
+	for root := filepath.Clean(dir); ; root = filepath.Dir(root) {
+		if stat(afs, filepath.Join(root, "go.mod")) {
+			return root, nil
+		}
+		if root == filepath.Dir(root) {
+			return "", fmt.Errorf("no go.mod found from %s", dir)
+		}
+	}
+}
+
+// walkGoFiles calls fn with every Go file of the module at root, parsed up
+// to its package clause, in lexical order. Generated files, hidden, vendor
+// and testdata directories and nested modules are skipped.
+func (g *Generator) walkGoFiles(root string, fn func(filePath string, src []byte, file *ast.File)) error {
+	return afero.Walk(g.Afs, root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if filePath != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+				stat(g.Afs, filepath.Join(filePath, "go.mod"))) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(filePath) != ".go" {
+			return nil
+		}
+
+		src, err := afero.ReadFile(g.Afs, filePath)
+		if err != nil {
+			return err
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), filePath, src, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		if !ast.IsGenerated(file) {
+			fn(filePath, src, file)
+		}
+		return nil
+	})
+}
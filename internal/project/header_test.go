@@ -0,0 +1,149 @@
+package project
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckHeaders(t *testing.T) {
+	viper.Set("author", "Steve Francia <spf@spf13.com>")
+	viper.Set("year", "2021")
+	viper.Set("license", "mit")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	cmdDir := createTestProject(t, fs)
+	root := filepath.Dir(cmdDir)
+	for name, content := range map[string]string{
+		"go.mod":                  "module github.com/acme/myproject\n",
+		"internal/other/other.go": "/*\nCopyright © 2021 Someone\nAll rights reserved.\n*/\n\npackage other\n",
+		"internal/gen/gen.go":     "// Code generated by stringer. DO NOT EDIT.\n\npackage gen\n",
+		"internal/build/build.go": "//go:build linux\n\n// Package build is built on linux.\npackage build\n",
+		"internal/bsd/bsd.go":     "// Copyright 2009 The Go Authors. All rights reserved.\n// Use of this source code is governed by a BSD-style\n// license that can be found in the LICENSE file.\n\npackage bsd\n",
+		"testdata/fixture.go":     "package fixture\n",
+		"tools/go.mod":            "module tools\n",
+		"tools/tools.go":          "package tools\n",
+	} {
+		if err := afero.WriteFile(fs, filepath.Join(root, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	generator := headerGenerator(t, fs)
+	results, err := generator.CheckHeaders(cmdDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := map[string]string{}
+	for _, result := range results {
+		rel, _ := filepath.Rel(root, result.FilePath)
+		statuses[filepath.ToSlash(rel)] = result.Status
+	}
+	want := map[string]string{
+		"cmd/root.go":                    HeaderOK,
+		"main.go":                        HeaderOK,
+		"internal/bsd/bsd.go":            HeaderMismatch,
+		"internal/build/build.go":        HeaderMissing,
+		"internal/config/config.go":      HeaderMissing,
+		"internal/config/config_test.go": HeaderMissing,
+		"internal/config/custom.go":      HeaderMissing,
+		"internal/other/other.go":        HeaderMismatch,
+		"internal/service/service.go":    HeaderMissing,
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("got statuses %v, want %v", statuses, want)
+	}
+
+	if err := generator.WriteHeaders(results); err != nil {
+		t.Fatal(err)
+	}
+	header := "/*\nCopyright © 2021 Steve Francia <spf@spf13.com>\nPermission is hereby granted"
+	assertRegistered(t, fs, filepath.Join(root, "internal", "service", "service.go"), header)
+	assertRegistered(t, fs, filepath.Join(root, "internal", "build", "build.go"), "*/\n\n//go:build linux\n")
+	// a file with a // header gets no second one
+	if data, err := afero.ReadFile(fs, filepath.Join(root, "internal", "bsd", "bsd.go")); err != nil || !strings.HasPrefix(string(data), "// Copyright 2009") {
+		t.Errorf("bsd.go got a second header:\n%s", data)
+	}
+
+	comment, err := extractBlockCommentBeforePackage(fs, filepath.Join(root, "internal", "build", "build.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(comment, "Copyright © 2021 Steve Francia") {
+		t.Errorf("got header %q of package build", comment)
+	}
+
+	if results, err = generator.CheckHeaders(root); err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.Status != HeaderOK && !strings.HasSuffix(result.FilePath, "other.go") && !strings.HasSuffix(result.FilePath, "bsd.go") {
+			t.Errorf("%s: %s after apply", result.FilePath, result.Status)
+		}
+	}
+}
+
+func TestUpdateCopyright(t *testing.T) {
+	viper.Set("author", "Steve Francia <spf@spf13.com>")
+	viper.Set("year", "2021")
+	viper.Set("license", "gpl3")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	cmdDir := createTestProject(t, fs)
+	root := filepath.Dir(cmdDir)
+	if err := afero.WriteFile(fs, filepath.Join(root, "go.mod"), []byte("module github.com/acme/myproject\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ranged := "/*\nCopyright © 2021-2024 Steve Francia\n*/\n\npackage cmd\n"
+	if err := afero.WriteFile(fs, filepath.Join(cmdDir, "serve.go"), []byte(ranged), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	generator := headerGenerator(t, fs)
+	if _, err := generator.UpdateCopyright(root, "26"); err == nil {
+		t.Error("an invalid year was accepted")
+	}
+	results, err := generator.UpdateCopyright(root, "2026")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.WriteHeaders(results); err != nil {
+		t.Fatal(err)
+	}
+
+	assertRegistered(t, fs, filepath.Join(root, "main.go"), "/*\nCopyright © 2021-2026 Steve Francia <spf@spf13.com>\n")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "serve.go"), "Copyright © 2021-2026 Steve Francia\n")
+	// the copyright of the license text itself is left as is
+	assertRegistered(t, fs, filepath.Join(root, "LICENSE"), "Copyright (C) 2007 Free Software Foundation")
+
+	if results, err = generator.UpdateCopyright(root, "2026"); err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.Status != HeaderOK {
+			t.Errorf("%s: %s on a second update", result.FilePath, result.Status)
+		}
+	}
+}
+
+// headerGenerator returns a generator for the license commands, configured
+// as cobra-cli configures it.
+func headerGenerator(t *testing.T, fs afero.Fs) *Generator {
+	t.Helper()
+
+	project, err := NewProject(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return generator
+}
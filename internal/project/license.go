@@ -0,0 +1,218 @@
+package project
+
+import (
+	_ "embed"
+	"fmt"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// spdxIDs holds the identifiers of the SPDX License List, one per line.
+//
+//go:embed spdx.txt
+var spdxIDs string
+
+// customLicenseCode is the Code of the license declared in the config file
+// as a map, with its header and text:
+//
+//	license:
+//	  header: This file is part of CLI application foo.
+//	  text: |
+//	    {{ .Copyright }}
+//
+//	    This is my license.
+const customLicenseCode = "custom"
+
+// customLicense returns the license of the config when it is a custom one
+// rather than the name of a license. Its keys are read from the map: with
+// the license flag bound, viper hides license.text.
+func customLicense() (header, text string, ok bool) {
+	license, ok := viper.Get("license").(map[string]any)
+	if !ok {
+		return "", "", false
+	}
+	header, _ = license["header"].(string)
+	text, _ = license["text"].(string)
+	return header, text, true
+}
+
+// isCustomLicense reports whether the license of the config is a custom one.
+func isCustomLicense() bool {
+	_, _, ok := customLicense()
+	return ok
+}
+
+// customLicenseFS returns templates with the custom license of the config
+// added as tpl/license_custom.tmpl and tpl/header_custom.tmpl, so that it
+// is rendered like the built-in ones. templates is returned as is when the
+// config names a license.
+func customLicenseFS(templates fs.FS) (fs.FS, error) {
+	header, text, ok := customLicense()
+	if !ok {
+		return templates, nil
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("the custom license of the config has no text")
+	}
+
+	mem := afero.NewMemMapFs()
+	if err := afero.WriteFile(mem, "license_"+customLicenseCode+".tmpl", []byte(text), 0644); err != nil {
+		return nil, err
+	}
+	if err := afero.WriteFile(mem, "header_"+customLicenseCode+".tmpl", []byte(strings.TrimSpace(header)), 0644); err != nil {
+		return nil, err
+	}
+
+	return &overlayFS{
+		upper: afero.NewIOFS(mem),
+		lower: templates,
+	}, nil
+}
+
+// licenseName returns the key of the license of the config in
+// contentLicenses.
+func licenseName() string {
+	if isCustomLicense() {
+		return customLicenseCode
+	}
+	return viper.GetString("license")
+}
+
+// findLicense returns the license of licenses named name: its key, its SPDX
+// identifier or one of its PossibleMatches. Case, spaces and punctuation are
+// ignored, so "Apache-2.0", "apache 2.0" and "apache2" all name apache2.
+// Any other identifier of the SPDX License List gives its spdxLicense.
+func findLicense(licenses map[string]*License, name string) (*License, error) {
+	if license, ok := licenses[name]; ok {
+		return license, nil
+	}
+
+	keys := slices.Sorted(maps.Keys(licenses))
+	for _, key := range keys {
+		if strings.EqualFold(licenses[key].SPDX, name) {
+			return licenses[key], nil
+		}
+	}
+
+	wanted := normalizeLicenseName(name)
+	for _, key := range keys {
+		for _, candidate := range licenseNames(key, licenses[key]) {
+			if candidate != "" && normalizeLicenseName(candidate) == wanted {
+				return licenses[key], nil
+			}
+		}
+	}
+
+	if id, ok := spdxIdentifier(name); ok {
+		return spdxLicense(id), nil
+	}
+
+	err := fmt.Errorf("unknown license %q, see cobra-cli licenses list for the supported ones", name)
+	if suggestion := suggestLicense(licenses, wanted); suggestion != "" {
+		err = fmt.Errorf("unknown license %q, did you mean %s?", name, suggestion)
+	}
+	return nil, err
+}
+
+// spdxIdentifier returns the identifier of the SPDX License List equal to
+// id but for its case, ok false when there is none.
+func spdxIdentifier(id string) (string, bool) {
+	for _, known := range strings.Fields(spdxIDs) {
+		if strings.EqualFold(known, id) {
+			return known, true
+		}
+	}
+	return "", false
+}
+
+// spdxLicense returns the license of the SPDX identifier id, which cobra-cli
+// has no text for: its files get an SPDX-License-Identifier header and the
+// project no LICENSE.
+func spdxLicense(id string) *License {
+	return &License{
+		Code:      id,
+		SPDX:      id,
+		Name:      id,
+		Header:    "SPDX-License-Identifier: " + id,
+		Copyright: fmt.Sprintf("Copyright © %s %s", year(), viper.GetString("author")),
+	}
+}
+
+// licenseNames returns the names license is known by, key being its key in
+// contentLicenses.
+func licenseNames(key string, license *License) []string {
+	return append([]string{key, license.Code, license.SPDX, license.Name}, license.PossibleMatches...)
+}
+
+// normalizeLicenseName returns name lower-cased with only its letters and
+// digits.
+func normalizeLicenseName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// suggestLicense returns the SPDX identifier of the license with a name
+// closest to the normalized name wanted, "" when none is close enough to be
+// a likely typo.
+func suggestLicense(licenses map[string]*License, wanted string) string {
+	suggestion, best := "", len(wanted)/3+1
+	for _, key := range slices.Sorted(maps.Keys(licenses)) {
+		license := licenses[key]
+		if license.SPDX == "" {
+			continue
+		}
+		for _, candidate := range licenseNames(key, license) {
+			if candidate == "" {
+				continue
+			}
+			if distance := editDistance(wanted, normalizeLicenseName(candidate)); distance < best {
+				suggestion, best = license.SPDX, distance
+			}
+		}
+	}
+	return suggestion
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// Licenses returns the licenses cobra-cli can generate, keyed by the name
+// used in contentLicenses, with those of the templates directory of the
+// config, if any.
+func Licenses(afs afero.Fs) (map[string]*License, error) {
+	tplFS, err := newTemplateFS(afs, viper.GetString("templates"))
+	if err != nil {
+		return nil, err
+	}
+	licenses := contentLicenses(tplFS)
+	delete(licenses, "none")
+	return licenses, nil
+}
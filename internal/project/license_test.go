@@ -0,0 +1,178 @@
+package project
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCustomLicense(t *testing.T) {
+	viper.Set("author", "Steve Francia <spf@spf13.com>")
+	viper.Set("year", "2020")
+	// bound as cobra-cli binds it, the flag hides the keys of the map
+	flags := pflag.NewFlagSet("cobra-cli", pflag.ContinueOnError)
+	flags.String("license", "none", "")
+	if err := viper.BindPFlag("license", flags.Lookup("license")); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("license", map[string]any{
+		"header": "This file is part of CLI application foo.",
+		"text": `{{ .Copyright }}
+
+This is my license. There are many like it, but this one is mine.
+`,
+	})
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	cmdDir := createTestProject(t, fs)
+	root := filepath.Dir(cmdDir)
+	addCommand(t, fs, cmdDir, "serve", "rootCmd")
+
+	license, err := afero.ReadFile(fs, filepath.Join(root, "LICENSE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Copyright © 2020 Steve Francia <spf@spf13.com>\n\nThis is my license. There are many like it, but this one is mine.\n"; string(license) != want {
+		t.Errorf("got LICENSE\n%s\nwant\n%s", license, want)
+	}
+
+	header := "/*\nCopyright © 2020 Steve Francia <spf@spf13.com>\nThis file is part of CLI application foo.\n*/\n\npackage "
+	for _, name := range []string{"main.go", "cmd/root.go", "cmd/serve.go"} {
+		assertRegistered(t, fs, filepath.Join(root, filepath.FromSlash(name)), header)
+	}
+
+	manifest, err := LoadManifest(fs, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.License != customLicenseCode {
+		t.Errorf("manifest records license %q, want %q", manifest.License, customLicenseCode)
+	}
+}
+
+func TestCustomLicenseWithoutText(t *testing.T) {
+	viper.Set("license", map[string]any{"header": "This file is part of foo."})
+	defer viper.Reset()
+
+	project, err := NewProject([]string{"myproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewProjectGenerator(afero.NewMemMapFs(), project); err == nil {
+		t.Fatal("a custom license without text was accepted")
+	}
+}
+
+func TestFindLicense(t *testing.T) {
+	licenses := contentLicenses(templates)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "apache2", want: "apache_2"},
+		{name: "Apache-2.0", want: "apache_2"},
+		{name: "apache 2.0", want: "apache_2"},
+		{name: "MIT License", want: "mit"},
+		{name: "GPLv3", want: "gpl_3"},
+		{name: "gpl-2.0-or-later", want: "gpl_2"},
+		{name: "mpl-2.0", want: "mpl_2"},
+		{name: "ISC", want: "isc"},
+		{name: "The Unlicense", want: "unlicense"},
+		{name: "EPL-2.0", want: "epl_2"},
+		{name: "boost", want: "bsl_1"},
+		{name: "0BSD", want: "0bsd"},
+		{name: "none", want: "none"},
+		{name: "LGPL-2.1-only", want: "LGPL-2.1-only"},
+		{name: "apache-1.1", want: "Apache-1.1"},
+	}
+	for _, tt := range tests {
+		license, err := findLicense(licenses, tt.name)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if license.Code != tt.want {
+			t.Errorf("%s resolves to %s, want %s", tt.name, license.Code, tt.want)
+		}
+	}
+
+	_, err := findLicense(licenses, "MPL-2.1")
+	if err == nil || !strings.Contains(err.Error(), "did you mean MPL-2.0?") {
+		t.Errorf("got error %v for MPL-2.1, want a suggestion of MPL-2.0", err)
+	}
+	if _, err := findLicense(licenses, "Acme-1.0"); err == nil {
+		t.Error("an unknown license was accepted")
+	}
+}
+
+func TestSPDXLicense(t *testing.T) {
+	viper.Set("author", "Steve Francia <spf@spf13.com>")
+	viper.Set("year", "2020")
+	viper.Set("license", "MPL-2.0")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	cmdDir := createTestProject(t, fs)
+	root := filepath.Dir(cmdDir)
+
+	assertRegistered(t, fs, filepath.Join(root, "LICENSE"), "Mozilla Public License Version 2.0\n")
+	header := "/*\nCopyright © 2020 Steve Francia <spf@spf13.com>\nThis Source Code Form is subject to the terms of the Mozilla Public\n"
+	assertRegistered(t, fs, filepath.Join(cmdDir, "root.go"), header)
+
+	manifest, err := LoadManifest(fs, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.License != "mpl_2" {
+		t.Errorf("manifest records license %q, want mpl_2", manifest.License)
+	}
+}
+
+func TestSPDXOnlyLicense(t *testing.T) {
+	viper.Set("author", "Steve Francia <spf@spf13.com>")
+	viper.Set("year", "2020")
+	viper.Set("license", "LGPL-2.1-only")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	cmdDir := createTestProject(t, fs)
+	root := filepath.Dir(cmdDir)
+	addCommand(t, fs, cmdDir, "serve", "rootCmd")
+
+	if stat(fs, filepath.Join(root, "LICENSE")) {
+		t.Error("a LICENSE was written for a license without text")
+	}
+	header := "/*\nCopyright © 2020 Steve Francia <spf@spf13.com>\nSPDX-License-Identifier: LGPL-2.1-only\n*/\n\npackage "
+	for _, name := range []string{"main.go", "cmd/root.go", "cmd/serve.go"} {
+		assertRegistered(t, fs, filepath.Join(root, filepath.FromSlash(name)), header)
+	}
+
+	manifest, err := LoadManifest(fs, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.License != "LGPL-2.1-only" {
+		t.Errorf("manifest records license %q, want LGPL-2.1-only", manifest.License)
+	}
+
+	// the license recorded in the manifest is the one the headers are
+	// checked against
+	viper.Set("license", "none")
+	if err := afero.WriteFile(fs, filepath.Join(root, "go.mod"), []byte("module github.com/acme/myproject\n\ngo 1.24\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results, err := headerGenerator(t, fs).CheckHeaders(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if filepath.Dir(result.FilePath) == cmdDir && result.Status != HeaderOK {
+			t.Errorf("%s: header %s %s", result.FilePath, result.Status, result.Reason)
+		}
+	}
+}
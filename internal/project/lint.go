@@ -0,0 +1,185 @@
+package project
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Severity is how bad a Diagnostic is: an error breaks the application or
+// its help, a warning is likely a mistake.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// LintRule is a check run by Lint on the command tree of a project.
+type LintRule struct {
+	ID          string
+	Description string
+}
+
+// LintRules are the rules of Lint, in the order they are run.
+var LintRules = []LintRule{
+	{"duplicate-command", "Two children of a command share a name or an alias."},
+	{"use-name", "Use does not start with the name of the command."},
+	{"duplicate-flag", "A flag is declared twice on a command."},
+	{"flag-shadow", "A flag shadows a persistent flag of an ancestor."},
+	{"shorthand-collision", "Two flags of a command share a shorthand letter."},
+	{"missing-short", "A command has no Short description."},
+	{"run-conflict", "Both variants of a Run function are set, one is never called."},
+	{"unregistered", "A command is declared but never registered to a parent."},
+	{"undefined-group", "A command is in a group its parent does not add, cobra panics on Execute."},
+}
+
+// Diagnostic is a mistake Lint found at File:Line, File being relative to
+// the project root.
+type Diagnostic struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Message  string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s (%s)", d.File, d.Line, d.Severity, d.Message, d.Rule)
+}
+
+// Lint checks the commands of t for the mistakes of LintRules, and returns
+// what it found sorted by file and line.
+func (t *CommandTree) Lint() []Diagnostic {
+	var diagnostics []Diagnostic
+	report := func(rule string, severity Severity, file string, line int, format string, args ...any) {
+		diagnostics = append(diagnostics, Diagnostic{
+			Rule:     rule,
+			Severity: severity,
+			File:     file,
+			Line:     line,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, node := range t.Commands() {
+		// the first child taking each name or alias
+		taken := map[string]*CommandNode{}
+		for _, child := range node.Children {
+			for _, name := range append([]string{child.Name}, child.Aliases...) {
+				if other, ok := taken[name]; ok && name != "" && other != child {
+					report("duplicate-command", SeverityError, child.File, child.Line,
+						"%q of %s is already taken by the command declared at %s:%d", name, node.Path(), other.File, other.Line)
+					continue
+				}
+				taken[name] = child
+			}
+		}
+
+		for _, child := range node.Children {
+			if child.GroupID != "" && !slices.ContainsFunc(node.Groups, func(group Group) bool { return group.ID == child.GroupID }) {
+				report("undefined-group", SeverityError, child.File, child.Line,
+					"%s is in group %q, which %s does not add", describe(child), child.GroupID, node.Path())
+			}
+		}
+
+		lintUse(node, report)
+		lintFlags(node, report)
+
+		if node.Short == "" {
+			report("missing-short", SeverityWarning, node.File, node.Line, "%s has no Short description", describe(node))
+		}
+		for _, hook := range node.hooks {
+			if strings.HasSuffix(hook, "E") && slices.Contains(node.hooks, strings.TrimSuffix(hook, "E")) {
+				report("run-conflict", SeverityWarning, node.File, node.Line,
+					"%s sets both %s and %s, %s is never called", describe(node), strings.TrimSuffix(hook, "E"), hook, strings.TrimSuffix(hook, "E"))
+			}
+		}
+	}
+
+	for _, orphan := range t.Orphans {
+		report("unregistered", SeverityWarning, orphan.File, orphan.Line, "%s is never registered with AddCommand", describe(orphan))
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].File != diagnostics[j].File {
+			return diagnostics[i].File < diagnostics[j].File
+		}
+		return diagnostics[i].Line < diagnostics[j].Line
+	})
+	return diagnostics
+}
+
+// lintUse checks the first word of Use is a command name and, when the
+// variable is named after the command as cobra-cli names it, that it is
+// that command.
+func lintUse(node *CommandNode, report func(string, Severity, string, int, string, ...any)) {
+	if node.Name == "" || strings.ContainsAny(node.Name[:1], "[<-{(") {
+		report("use-name", SeverityError, node.File, node.Line, "Use %q of %s does not start with the name of the command", node.Use, describe(node))
+		return
+	}
+
+	base, ok := strings.CutSuffix(node.Var, "Cmd")
+	if !ok || base == "" || node.Var == "rootCmd" || node.parent == nil {
+		return
+	}
+	name := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(node.Name))
+	if !strings.HasSuffix(strings.ToLower(base), name) {
+		report("use-name", SeverityWarning, node.File, node.Line, "Use %q of %s names command %q", node.Use, node.Var, node.Name)
+	}
+}
+
+// lintFlags checks the flags of node against each other and against the
+// persistent flags node inherits from its ancestors.
+func lintFlags(node *CommandNode, report func(string, Severity, string, int, string, ...any)) {
+	names := map[string]CommandFlag{}
+	shorthands := map[string]CommandFlag{}
+	for _, flag := range node.Flags {
+		if other, ok := names[flag.Name]; ok {
+			report("duplicate-flag", SeverityError, flag.File, flag.Line,
+				"flag --%s of %s is already declared at %s:%d", flag.Name, describe(node), other.File, other.Line)
+			continue
+		}
+		names[flag.Name] = flag
+
+		if other, ok := shorthands[flag.Shorthand]; ok && flag.Shorthand != "" {
+			report("shorthand-collision", SeverityError, flag.File, flag.Line,
+				"flag --%s of %s takes shorthand -%s of --%s declared at %s:%d", flag.Name, describe(node), flag.Shorthand, other.Name, other.File, other.Line)
+			continue
+		}
+		if flag.Shorthand != "" {
+			shorthands[flag.Shorthand] = flag
+		}
+	}
+
+	// the flags inherited from the closest ancestor hide those further up,
+	// which are checked against it instead
+	inherited := map[string]bool{}
+	for ancestor := node.parent; ancestor != nil; ancestor = ancestor.parent {
+		for _, persistent := range ancestor.Flags {
+			if !persistent.Persistent || inherited[persistent.Name] {
+				continue
+			}
+			inherited[persistent.Name] = true
+
+			if flag, ok := names[persistent.Name]; ok {
+				report("flag-shadow", SeverityWarning, flag.File, flag.Line,
+					"flag --%s of %s shadows the persistent flag of %s declared at %s:%d", flag.Name, describe(node), ancestor.Path(), persistent.File, persistent.Line)
+				continue
+			}
+			if flag, ok := shorthands[persistent.Shorthand]; ok && persistent.Shorthand != "" {
+				report("shorthand-collision", SeverityError, flag.File, flag.Line,
+					"flag --%s of %s takes shorthand -%s of the persistent flag --%s of %s declared at %s:%d", flag.Name, describe(node), flag.Shorthand, persistent.Name, ancestor.Path(), persistent.File, persistent.Line)
+			}
+		}
+	}
+}
+
+// describe names node in a diagnostic, by its variable when it has one.
+func describe(node *CommandNode) string {
+	if node.Var != "" {
+		return node.Var
+	}
+	return fmt.Sprintf("command %q", node.Path())
+}
@@ -0,0 +1,80 @@
+package project
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tree := commandTree(t, map[string]string{
+		"cmd/root.go": `package cmd
+
+import "github.com/spf13/cobra"
+
+var rootCmd = &cobra.Command{
+	Use:   "app",
+	Short: "The application",
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringP("config", "c", "", "config file")
+	rootCmd.AddCommand(serveCmd, runCmd, statusCmd)
+}
+`,
+		"cmd/serve.go": `package cmd
+
+import "github.com/spf13/cobra"
+
+var serveCmd = &cobra.Command{
+	Use:     "serve",
+	Aliases: []string{"run"},
+	Short:   "Start the server",
+	Run:     func(cmd *cobra.Command, args []string) {},
+	RunE:    func(cmd *cobra.Command, args []string) error { return nil },
+}
+
+func init() {
+	serveCmd.Flags().String("config", "", "server config")
+	serveCmd.Flags().IntP("count", "c", 1, "count")
+	serveCmd.Flags().BoolP("check", "c", false, "check")
+}
+`,
+		"cmd/run.go": `package cmd
+
+import "github.com/spf13/cobra"
+
+var runCmd = &cobra.Command{
+	Use:     "run",
+	GroupID: "ops",
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "[status]",
+	Short: "Show the status",
+}
+
+var infoCmd = &cobra.Command{
+	Use:   "about",
+	Short: "About the application",
+}
+`,
+	})
+
+	var got []string
+	for _, diagnostic := range tree.Lint() {
+		got = append(got, diagnostic.String())
+	}
+	want := []string{
+		`cmd/run.go:5: error: "run" of app is already taken by the command declared at cmd/serve.go:5 (duplicate-command)`,
+		`cmd/run.go:5: error: runCmd is in group "ops", which app does not add (undefined-group)`,
+		`cmd/run.go:5: warning: runCmd has no Short description (missing-short)`,
+		`cmd/run.go:10: error: Use "[status]" of statusCmd does not start with the name of the command (use-name)`,
+		`cmd/run.go:15: warning: infoCmd is never registered with AddCommand (unregistered)`,
+		`cmd/serve.go:5: warning: serveCmd sets both Run and RunE, Run is never called (run-conflict)`,
+		`cmd/serve.go:14: warning: flag --config of serveCmd shadows the persistent flag of app declared at cmd/root.go:11 (flag-shadow)`,
+		`cmd/serve.go:16: error: flag --check of serveCmd takes shorthand -c of --count declared at cmd/serve.go:15 (shorthand-collision)`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got diagnostics\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,226 @@
+package project
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestName is the name of the generation manifest at the project root.
+const ManifestName = ".cobra-cli.yaml"
+
+// SnapshotDir holds, relative to the project root, a copy of every template
+// the project was generated from, named after its hash.
+const SnapshotDir = ".cobra-cli/templates"
+
+// Manifest records what cobra-cli generated in a project and from which
+// inputs, so that later commands can find the project root and tell
+// untouched files from the ones the user edited.
+type Manifest struct {
+	Version      string            `yaml:"version"`
+	Module       string            `yaml:"module"`
+	AppName      string            `yaml:"appName"`
+	Author       string            `yaml:"author"`
+	License      string            `yaml:"license"`
+	Year         string            `yaml:"year"`
+	Pack         string            `yaml:"pack,omitempty"`
+	Vars         map[string]string `yaml:"vars,omitempty"`
+	GroupImports bool              `yaml:"groupImports,omitempty"`
+	Files        []ManifestFile    `yaml:"files"`
+}
+
+// ManifestFile is a generated file, relative to the project root, with the
+// template it came from and the hash of its content when generated.
+// TemplateHash names the snapshot of the template under SnapshotDir.
+// Command and Parent are set for files generated by `cobra-cli add`, with
+// Registration when the command is registered by its parent and the flags,
+// args and aliases it was generated with.
+type ManifestFile struct {
+	Path         string      `yaml:"path"`
+	Template     string      `yaml:"template"`
+	TemplateHash string      `yaml:"templateHash,omitempty"`
+	Hash         string      `yaml:"hash"`
+	Command      string      `yaml:"command,omitempty"`
+	Parent       string      `yaml:"parent,omitempty"`
+	Registration string      `yaml:"registration,omitempty"`
+	Spec         CommandSpec `yaml:",inline"`
+}
+
+// hashContent returns the hash recorded for generated content.
+func hashContent(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(ensureLF(data)))
+}
+
+// snapshotPath returns the path, relative to the project root, of the
+// snapshot of the template with the given hash.
+func snapshotPath(templateHash string) string {
+	return filepath.Join(filepath.FromSlash(SnapshotDir), strings.TrimPrefix(templateHash, "sha256:")+".tmpl")
+}
+
+// File returns the record of the file at path, relative to the root.
+func (m *Manifest) File(path string) (ManifestFile, bool) {
+	path = filepath.ToSlash(path)
+	for _, file := range m.Files {
+		if file.Path == path {
+			return file, true
+		}
+	}
+	return ManifestFile{}, false
+}
+
+// Modified reports whether data, the current content of a recorded file,
+// differs from what was generated.
+func (f ManifestFile) Modified(data []byte) bool {
+	return hashContent(data) != f.Hash
+}
+
+func (m *Manifest) setFile(file ManifestFile) {
+	for i := range m.Files {
+		if m.Files[i].Path == file.Path {
+			m.Files[i] = file
+			return
+		}
+	}
+	m.Files = append(m.Files, file)
+	sort.Slice(m.Files, func(i, j int) bool {
+		return m.Files[i].Path < m.Files[j].Path
+	})
+}
+
+// LoadManifest reads the manifest of the project rooted at root.
+func LoadManifest(afs afero.Fs, root string) (*Manifest, error) {
+	data, err := afero.ReadFile(afs, filepath.Join(root, ManifestName))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	if err := yaml.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(root, ManifestName), err)
+	}
+	return manifest, nil
+}
+
+// FindProjectRoot returns the closest directory holding a manifest, looking
+// in dir and its parents. It fails with fs.ErrNotExist when there is none.
+func FindProjectRoot(afs afero.Fs, dir string) (string, error) {
+	for dir = filepath.Clean(dir); ; dir = filepath.Dir(dir) {
+		if stat(afs, filepath.Join(dir, ManifestName)) {
+			return dir, nil
+		}
+		if dir == filepath.Dir(dir) {
+			return "", fmt.Errorf("no %s found: %w", ManifestName, fs.ErrNotExist)
+		}
+	}
+}
+
+// recordsManifest reports whether the files generated are recorded in the
+// manifest of the project: a new project gets one, and a project with one
+// keeps it up to date. A project generated before manifests existed gets
+// none, since its author, license and files cannot be told from it.
+func (g *Generator) recordsManifest() bool {
+	return g.Root != "" && (g.initializing || g.Manifest != nil)
+}
+
+// newManifest returns the manifest of a project generated now.
+func (g *Generator) newManifest() *Manifest {
+	manifest := &Manifest{
+		Module:       g.Project.PkgName,
+		AppName:      g.Project.AppName,
+		Author:       viper.GetString("author"),
+		License:      g.Project.Legal.Code,
+		Year:         year(),
+		Vars:         g.Project.Vars,
+		GroupImports: g.GroupImports,
+	}
+	if g.Pack != nil {
+		manifest.Pack = g.Pack.String()
+	}
+	return manifest
+}
+
+// manifestFile returns the manifest updated with the rendered files, the
+// generated content by target path. Files written aside because of a
+// conflict are not recorded.
+func (g *Generator) manifestFile(rendered map[string][]byte) ([]byte, error) {
+	manifest := g.newManifest()
+	if g.Manifest != nil {
+		existing := *g.Manifest
+		existing.Files = append([]ManifestFile(nil), g.Manifest.Files...)
+		manifest = &existing
+	}
+	manifest.Version = Version()
+
+	for _, content := range g.Content {
+		data, ok := rendered[content.FilePath]
+		if content.Dirty || !ok {
+			continue
+		}
+
+		rel, err := filepath.Rel(g.Root, content.FilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		file := ManifestFile{
+			Path:         filepath.ToSlash(rel),
+			Template:     content.TemplateFilePath,
+			TemplateHash: hashContent([]byte(content.TemplateContent)),
+			Hash:         hashContent(data),
+		}
+		if command, ok := content.Data.(Command); ok {
+			file.Command = command.CmdName
+			file.Parent = command.CmdParent
+			if command.Registration == RegisterParent {
+				file.Registration = string(command.Registration)
+			}
+			file.Spec = command.Spec
+		}
+		manifest.setFile(file)
+	}
+
+	return manifest.encode()
+}
+
+// encode returns the manifest as YAML.
+func (m *Manifest) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(m); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), encoder.Close()
+}
+
+// loadProjectManifest finds the project root from dir using its manifest.
+// Projects generated before manifests existed are not an error: Root and
+// Manifest are left empty. A project generated with its imports grouped
+// gets the files generated in it grouped too.
+func (g *Generator) loadProjectManifest(dir string) error {
+	root, err := FindProjectRoot(g.Afs, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	manifest, err := LoadManifest(g.Afs, root)
+	if err != nil {
+		return err
+	}
+
+	g.Root = root
+	g.Manifest = manifest
+	g.GroupImports = g.GroupImports || manifest.GroupImports
+	return nil
+}
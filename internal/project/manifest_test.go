@@ -0,0 +1,119 @@
+package project
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	viper.Set("license", "none")
+	viper.Set("author", "Jane Doe")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+
+	project, err := NewProject([]string{"myproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project.SetPkgName("github.com/acme/myproject")
+
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.PrepareModels(); err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.CreateProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	root := project.AbsolutePath
+	manifest, err := LoadManifest(fs, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if manifest.Module != "github.com/acme/myproject" || manifest.Author != "Jane Doe" || manifest.License != "none" ||
+		manifest.Pack != "default@1.0.0" || manifest.Version == "" {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+
+	rootGo, ok := manifest.File("cmd/root.go")
+	if !ok || rootGo.Template != "tpl/root_none.tmpl" {
+		t.Fatalf("cmd/root.go is not recorded: %+v", manifest.Files)
+	}
+
+	data, err := afero.ReadFile(fs, filepath.Join(root, "cmd", "root.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rootGo.Modified(data) {
+		t.Fatal("freshly generated cmd/root.go is reported as modified")
+	}
+	if !rootGo.Modified(append(data, "// edited\n"...)) {
+		t.Fatal("edited cmd/root.go is not reported as modified")
+	}
+
+	// an unlicensed project has no LICENSE, the manifest alone marks its root
+	command, err := NewProject([]string{"serve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	command.SetAbsolutePath(filepath.Join(root, "cmd", "serve"))
+
+	generator, err = NewProjectGenerator(fs, command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.AddCommandProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err = LoadManifest(fs, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serve, ok := manifest.File("cmd/serve.go")
+	if !ok || serve.Command != "serve" || serve.Parent != "rootCmd" {
+		t.Fatalf("cmd/serve.go is not recorded: %+v", manifest.Files)
+	}
+	if _, ok := manifest.File("main.go"); !ok {
+		t.Fatal("files of the project were dropped from the manifest")
+	}
+}
+
+func TestAddWithoutManifest(t *testing.T) {
+	viper.Set("license", "mit")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	cmdDir := createTestProject(t, fs)
+	root := filepath.Dir(cmdDir)
+
+	// a project generated before manifests existed
+	if err := fs.Remove(filepath.Join(root, ManifestName)); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.RemoveAll(filepath.Join(root, SnapshotDir)); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("license", "none")
+
+	// run from the root, where the LICENSE and root.go are found
+	addCommand(t, fs, root, "legacy", "rootCmd")
+	if !stat(fs, filepath.Join(cmdDir, "legacy.go")) {
+		t.Fatal("the command was not added")
+	}
+
+	if stat(fs, filepath.Join(root, ManifestName)) {
+		t.Fatal("add created a manifest in a project without one")
+	}
+	if stat(fs, filepath.Join(root, SnapshotDir)) {
+		t.Fatal("add staged template snapshots in a project without a manifest")
+	}
+}
@@ -0,0 +1,98 @@
+package project
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	markerOurs   = "<<<<<<< current"
+	markerBase   = "||||||| original"
+	markerSplit  = "======="
+	markerTheirs = ">>>>>>> upgraded"
+)
+
+// matchLines maps every line of a to the line of b it is kept as in the
+// shortest edit script from a to b, or -1 when it is deleted.
+func matchLines(a, b []string) []int {
+	matches := make([]int, len(a))
+	for i := range matches {
+		matches[i] = -1
+	}
+
+	for _, op := range diffLines(a, b) {
+		if op.Kind == diffEqual {
+			matches[op.A] = op.B
+		}
+	}
+	return matches
+}
+
+// merge3 merges the changes made from base to ours and from base to theirs,
+// line by line. Where both sides changed the same lines differently, the
+// result holds diff3-style conflict markers and conflicts is the number of
+// such regions.
+func merge3(base, ours, theirs []byte) (merged []byte, conflicts int) {
+	baseLines, ourLines, theirLines := splitLines(base), splitLines(ours), splitLines(theirs)
+	toOurs, toTheirs := matchLines(baseLines, ourLines), matchLines(baseLines, theirLines)
+
+	var sb strings.Builder
+	i, a, b := 0, 0, 0
+	for {
+		// the next base line kept unchanged on both sides
+		k := i
+		for k < len(baseLines) && (toOurs[k] < a || toTheirs[k] < b) {
+			k++
+		}
+
+		endA, endB := len(ourLines), len(theirLines)
+		if k < len(baseLines) {
+			endA, endB = toOurs[k], toTheirs[k]
+		}
+
+		conflicts += mergeChunk(&sb, baseLines[i:k], ourLines[a:endA], theirLines[b:endB])
+
+		if k == len(baseLines) {
+			return []byte(sb.String()), conflicts
+		}
+
+		sb.WriteString(baseLines[k])
+		i, a, b = k+1, endA+1, endB+1
+	}
+}
+
+// mergeChunk writes the merge of a region between two stable lines and
+// returns 1 when it is a conflict.
+func mergeChunk(sb *strings.Builder, base, ours, theirs []string) int {
+	switch {
+	case slices.Equal(ours, base):
+		writeLines(sb, theirs)
+	case slices.Equal(theirs, base), slices.Equal(ours, theirs):
+		writeLines(sb, ours)
+	default:
+		writeMarker(sb, markerOurs)
+		writeLines(sb, ours)
+		writeMarker(sb, markerBase)
+		writeLines(sb, base)
+		writeMarker(sb, markerSplit)
+		writeLines(sb, theirs)
+		writeMarker(sb, markerTheirs)
+		return 1
+	}
+	return 0
+}
+
+func writeLines(sb *strings.Builder, lines []string) {
+	for _, line := range lines {
+		sb.WriteString(line)
+	}
+}
+
+// writeMarker writes a conflict marker on its own line, ending the previous
+// line first when it had no line terminator.
+func writeMarker(sb *strings.Builder, marker string) {
+	if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(marker + "\n")
+}
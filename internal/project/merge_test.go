@@ -0,0 +1,68 @@
+package project
+
+import (
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+		conflicts          int
+	}{
+		{
+			name:   "only theirs changed",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nc\n",
+			theirs: "a\nB\nc\n",
+			want:   "a\nB\nc\n",
+		},
+		{
+			name:   "only ours changed",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nc\nd\n",
+			theirs: "a\nb\nc\n",
+			want:   "a\nb\nc\nd\n",
+		},
+		{
+			name:   "both changed apart",
+			base:   "a\nb\nc\nd\ne\n",
+			ours:   "A\nb\nc\nd\ne\n",
+			theirs: "a\nb\nc\nd\nE\n",
+			want:   "A\nb\nc\nd\nE\n",
+		},
+		{
+			name:   "both made the same change",
+			base:   "a\nb\nc\n",
+			ours:   "a\nx\nc\n",
+			theirs: "a\nx\nc\n",
+			want:   "a\nx\nc\n",
+		},
+		{
+			name:      "both changed the same line",
+			base:      "a\nb\nc\n",
+			ours:      "a\nours\nc\n",
+			theirs:    "a\ntheirs\nc\n",
+			want:      "a\n<<<<<<< current\nours\n||||||| original\nb\n=======\ntheirs\n>>>>>>> upgraded\nc\n",
+			conflicts: 1,
+		},
+		{
+			name:      "missing final newline",
+			base:      "a\nb",
+			ours:      "a\nours",
+			theirs:    "a\ntheirs",
+			want:      "a\n<<<<<<< current\nours\n||||||| original\nb\n=======\ntheirs\n>>>>>>> upgraded\n",
+			conflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := merge3([]byte(tt.base), []byte(tt.ours), []byte(tt.theirs))
+			if string(merged) != tt.want || conflicts != tt.conflicts {
+				t.Errorf("merge3() = %q, %d conflict(s), want %q, %d", merged, conflicts, tt.want, tt.conflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,403 @@
+package project
+
+import (
+	"fmt"
+	"github.com/spf13/afero"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"golang.org/x/mod/modfile"
+	"path"
+	"path/filepath"
+	"slices"
+	"unicode"
+)
+
+// PlanMove computes moving the command name under the command to, both
+// given as the name of the command or as its variable, in the cmd package
+// of the project holding dir. The call registering the command is rewritten
+// in place when the command registers itself from its own init(), and is
+// otherwise moved into the init() registering the children of its new
+// parent. Moving a command under itself or one of its descendants fails.
+// A command in a group its new parent does not add yet gets the group added
+// to the new parent, with the title it had under the former one.
+//
+// With pkgName set, the file of the command moves into the sub-package
+// cmd/<pkgName>: its variable is exported and the files of package cmd
+// referring to it, tests included, import the sub-package. The moved file
+// may only use what it declares itself.
+func (g *Generator) PlanMove(dir, name, to, pkgName string) (*Refactoring, error) {
+	rootGo, err := g.locateRootGo(dir)
+	if err != nil {
+		return nil, err
+	}
+	cmdDir := filepath.Dir(rootGo)
+
+	pkg, err := parseCommandPackage(g.Afs, cmdDir)
+	if err != nil {
+		return nil, err
+	}
+
+	childVar, err := pkg.commandVar(name)
+	if err != nil {
+		return nil, err
+	}
+	if childVar == "rootCmd" {
+		return nil, fmt.Errorf("the root command cannot be moved")
+	}
+	parentVar, err := pkg.commandVar(to)
+	if err != nil {
+		return nil, err
+	}
+
+	// walking up from the new parent must not reach the command
+	seen := map[string]bool{}
+	for ancestor := parentVar; ancestor != "" && !seen[ancestor]; ancestor = pkg.parent(ancestor) {
+		if ancestor == childVar {
+			return nil, fmt.Errorf("cannot move %s under %s: %s is %s itself or one of its descendants", childVar, parentVar, parentVar, childVar)
+		}
+		seen[ancestor] = true
+	}
+	if pkgName == "" && pkg.parent(childVar) == parentVar {
+		return nil, fmt.Errorf("%s is already a child of %s", childVar, parentVar)
+	}
+
+	m := &move{
+		g:         g,
+		pkg:       pkg,
+		childVar:  childVar,
+		parentVar: parentVar,
+		childFile: pkg.vars[childVar],
+		childRef:  childVar,
+		edits:     map[string][]textEdit{},
+		dropped:   map[ast.Stmt]bool{},
+		inserts:   map[string][]string{},
+		imports:   map[string]bool{},
+	}
+
+	if pkgName != "" {
+		if !token.IsIdentifier(pkgName) || pkgName == "cmd" {
+			return nil, fmt.Errorf("invalid package name %q", pkgName)
+		}
+		for _, other := range pkg.commands() {
+			if pkg.vars[other] == m.childFile && other != childVar {
+				return nil, fmt.Errorf("%s declares %s along with %s, it cannot move to package %s", m.childFile, childVar, other, pkgName)
+			}
+		}
+
+		importPath, err := g.importPath(cmdDir)
+		if err != nil {
+			return nil, err
+		}
+		m.importPath = path.Join(importPath, pkgName)
+		m.exported = exportName(childVar)
+		m.childRef = pkgName + "." + m.exported
+	}
+
+	m.moveGroup()
+	if err := m.moveRegistration(); err != nil {
+		return nil, err
+	}
+	if pkgName != "" {
+		if err := m.moveFile(pkgName); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &Refactoring{}
+	target := m.childFile
+	if pkgName != "" {
+		target = filepath.Join(cmdDir, pkgName, filepath.Base(m.childFile))
+		if stat(g.Afs, target) {
+			return nil, fmt.Errorf("%s already exists", target)
+		}
+		r.remove(m.childFile)
+	}
+
+	for _, filePath := range append(pkg.paths(), pkg.testPaths()...) {
+		src, changed, err := m.source(filePath)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !changed:
+		case filePath == m.childFile && target != m.childFile:
+			r.create(target, src)
+		default:
+			r.update(filePath, src)
+		}
+	}
+
+	if r.manifest = g.manifestCopy(); r.manifest != nil {
+		for i, file := range r.manifest.Files {
+			if file.Path == g.manifestPath(m.childFile) {
+				r.manifest.Files[i].Path = g.manifestPath(target)
+				r.manifest.Files[i].Parent = parentVar
+			}
+		}
+	}
+	return r, nil
+}
+
+// move holds the state of PlanMove: the edits made to the files of the
+// package, applied when their source is asked for.
+type move struct {
+	g   *Generator
+	pkg *commandPackage
+
+	childVar, parentVar string
+	childFile           string
+	// childRef is how package cmd refers to the command once moved
+	childRef   string
+	exported   string
+	importPath string
+
+	edits   map[string][]textEdit
+	dropped map[ast.Stmt]bool
+	// group is added to the new parent in groupFile, nil when the
+	// command is in no group or the new parent adds it already
+	group     *Group
+	groupFile string
+	// inserts holds the statements added to the init() of a file once
+	// edited, imports the files importing the sub-package
+	inserts map[string][]string
+	imports map[string]bool
+}
+
+// moveRegistration rewrites or removes the calls registering the command,
+// and plans its registration to the new parent.
+func (m *move) moveRegistration() error {
+	reregister := true
+	for _, registration := range m.pkg.registrations() {
+		if !slices.Contains(registration.children, m.childVar) {
+			continue
+		}
+
+		src, err := afero.ReadFile(m.g.Afs, registration.file)
+		if err != nil {
+			return err
+		}
+
+		if m.importPath == "" && registration.file == m.childFile && len(registration.children) == 1 {
+			// the command registers itself: only its parent changes
+			receiver := ast.Unparen(registration.stmt.(*ast.ExprStmt).X).(*ast.CallExpr).Fun.(*ast.SelectorExpr).X
+			m.edit(registration.file, textEdit{
+				start: m.pkg.fset.Position(receiver.Pos()).Offset,
+				end:   m.pkg.fset.Position(receiver.End()).Offset,
+				text:  m.parentVar,
+			})
+			m.dropped[registration.stmt] = true
+			reregister = false
+			continue
+		}
+
+		kept := slices.DeleteFunc(slices.Clone(registration.children), func(child string) bool { return child == m.childVar })
+		m.edit(registration.file, registrationEdit(m.pkg, src, registration, kept))
+		m.dropped[registration.stmt] = true
+	}
+
+	if reregister {
+		parentFile := m.pkg.initFile(m.parentVar)
+		switch {
+		case m.importPath == "" && parentFile == "":
+			parentFile = m.childFile
+		case m.importPath != "" && parentFile == m.childFile:
+			// the file leaves package cmd
+			parentFile = m.pkg.vars[m.parentVar]
+		}
+		m.insert(parentFile, fmt.Sprintf("%s.AddCommand(%s)", m.parentVar, m.childRef))
+	}
+	return nil
+}
+
+// moveGroup plans adding the group of the command to its new parent, so
+// that cobra does not panic on a GroupID its parent does not add.
+func (m *move) moveGroup() {
+	_, lit := commandLiteral(m.pkg.files[m.childFile], m.childVar)
+	if lit == nil {
+		return
+	}
+	kv := literalField(lit, "GroupID")
+	if kv == nil {
+		return
+	}
+	id, _ := stringLit(kv.Value)
+	if id == "" || slices.Contains(m.pkg.groups(m.parentVar), id) {
+		return
+	}
+
+	title := m.pkg.groupTitle(m.pkg.parent(m.childVar), id)
+	if title == "" {
+		title = exportName(id) + " Commands"
+	}
+	m.group = &Group{ID: id, Title: title}
+	m.groupFile = m.pkg.vars[m.parentVar]
+}
+
+// moveFile turns the file of the command into a file of the sub-package
+// pkgName, and makes package cmd and its tests refer to the exported
+// command.
+func (m *move) moveFile(pkgName string) error {
+	file := m.pkg.files[m.childFile]
+	m.edit(m.childFile, textEdit{
+		start: m.pkg.fset.Position(file.Name.Pos()).Offset,
+		end:   m.pkg.fset.Position(file.Name.End()).Offset,
+		text:  pkgName,
+	})
+
+	var err error
+	m.packageIdents(file, func(ident *ast.Ident) {
+		switch declaring := m.pkg.vars[ident.Name]; {
+		case ident.Name == m.childVar:
+			m.replace(m.childFile, ident, m.exported)
+		case declaring != "" && declaring != m.childFile && err == nil:
+			err = fmt.Errorf("%s uses %s of package cmd at %s, it cannot move to package %s", m.childVar, ident.Name, m.pkg.fset.Position(ident.Pos()), pkgName)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, filePath := range append(m.pkg.paths(), m.pkg.testPaths()...) {
+		if filePath == m.childFile {
+			continue
+		}
+		other, ok := m.pkg.files[filePath]
+		if !ok {
+			other = m.pkg.tests[filePath]
+		}
+		m.packageIdents(other, func(ident *ast.Ident) {
+			switch {
+			case ident.Name == m.childVar:
+				m.replace(filePath, ident, m.childRef)
+				m.imports[filePath] = true
+			case m.pkg.vars[ident.Name] == m.childFile && err == nil:
+				err = fmt.Errorf("%s is used at %s, it cannot move to package %s", ident.Name, m.pkg.fset.Position(ident.Pos()), pkgName)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// packageIdents calls fn with every identifier of file that may refer to a
+// package-level variable, leaving out the statements already dropped.
+func (m *move) packageIdents(file *ast.File, fn func(*ast.Ident)) {
+	var visit func(node ast.Node) bool
+	visit = func(node ast.Node) bool {
+		switch node := node.(type) {
+		case ast.Stmt:
+			return !m.dropped[node]
+		case *ast.SelectorExpr:
+			ast.Inspect(node.X, visit)
+			return false
+		case *ast.KeyValueExpr:
+			// the keys of a cobra.Command literal are fields
+			ast.Inspect(node.Value, visit)
+			return false
+		case *ast.Ident:
+			fn(node)
+		}
+		return true
+	}
+	ast.Inspect(file, visit)
+}
+
+func (m *move) edit(filePath string, edit textEdit) {
+	m.edits[filePath] = append(m.edits[filePath], edit)
+}
+
+func (m *move) replace(filePath string, ident *ast.Ident, text string) {
+	m.edit(filePath, textEdit{
+		start: m.pkg.fset.Position(ident.Pos()).Offset,
+		end:   m.pkg.fset.Position(ident.End()).Offset,
+		text:  text,
+	})
+}
+
+func (m *move) insert(filePath, stmt string) {
+	m.inserts[filePath] = append(m.inserts[filePath], stmt)
+	if m.importPath != "" {
+		m.imports[filePath] = true
+	}
+}
+
+// source returns the content of filePath once moved, and whether it
+// changed.
+func (m *move) source(filePath string) ([]byte, bool, error) {
+	if len(m.edits[filePath]) == 0 && len(m.inserts[filePath]) == 0 && filePath != m.groupFile {
+		return nil, false, nil
+	}
+
+	src, err := afero.ReadFile(m.g.Afs, filePath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if src, err = format.Source(applyEdits(src, m.edits[filePath])); err != nil {
+		return nil, false, fmt.Errorf("move %s in %s: %w", m.childVar, filePath, err)
+	}
+	if src, err = dropEmptyInits(src, filePath); err != nil {
+		return nil, false, err
+	}
+
+	for _, stmt := range m.inserts[filePath] {
+		if src, err = insertIntoInit(src, filePath, stmt, func(stmt ast.Stmt) bool {
+			return addCommandCall(stmt, m.parentVar) != nil
+		}); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if filePath == m.groupFile {
+		if src, err = addGroups(src, filePath, m.parentVar, []Group{*m.group}); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if m.imports[filePath] {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, filePath, src, parser.ImportsOnly)
+		if err != nil {
+			return nil, false, err
+		}
+		if src, err = format.Source(addImport(fset, src, file, m.importPath)); err != nil {
+			return nil, false, fmt.Errorf("import %s in %s: %w", m.importPath, filePath, err)
+		}
+	}
+	return src, true, nil
+}
+
+// importPath returns the import path of the package in dir, from the
+// go.mod of its module.
+func (g *Generator) importPath(dir string) (string, error) {
+	for root := filepath.Clean(dir); ; root = filepath.Dir(root) {
+		data, err := afero.ReadFile(g.Afs, filepath.Join(root, "go.mod"))
+		if err == nil {
+			modulePath := modfile.ModulePath(data)
+			if modulePath == "" {
+				return "", fmt.Errorf("%s declares no module", filepath.Join(root, "go.mod"))
+			}
+
+			rel, err := filepath.Rel(root, dir)
+			if err != nil {
+				return "", err
+			}
+			return path.Join(modulePath, filepath.ToSlash(rel)), nil
+		}
+		if root == filepath.Dir(root) {
+			return "", fmt.Errorf("no go.mod found from %s", dir)
+		}
+	}
+}
+
+// exportName returns name with its first letter upper-cased.
+func exportName(name string) string {
+	if name == "" {
+		return name
+	}
+	return string(unicode.ToUpper(rune(name[0]))) + name[1:]
+}
@@ -0,0 +1,138 @@
+package project
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMove(t *testing.T) {
+	viper.Set("license", "none")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	cmdDir := createTestProject(t, fs)
+	if err := afero.WriteFile(fs, filepath.Join(filepath.Dir(cmdDir), "go.mod"), []byte("module github.com/acme/myproject\n\ngo 1.24\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	addCommand(t, fs, cmdDir, "admin", "rootCmd")
+	addCommand(t, fs, cmdDir, "user", "rootCmd")
+	addCommand(t, fs, cmdDir, "list", "userCmd")
+
+	if _, err := planMove(t, fs, cmdDir, "user", "list", ""); err == nil || !strings.Contains(err.Error(), "descendants") {
+		t.Fatalf("got error %v, want a cycle", err)
+	}
+	if _, err := planMove(t, fs, cmdDir, "user", "user", ""); err == nil {
+		t.Fatal("user was moved under itself")
+	}
+	if _, err := planMove(t, fs, cmdDir, "user", "root", ""); err == nil || !strings.Contains(err.Error(), "already a child") {
+		t.Fatalf("got error %v, want user to be a child of root already", err)
+	}
+
+	if _, err := planMove(t, fs, cmdDir, "user", "admin", ""); err != nil {
+		t.Fatal(err)
+	}
+	assertRegistered(t, fs, filepath.Join(cmdDir, "user.go"), "\tadminCmd.AddCommand(userCmd)\n")
+
+	// the tests of package cmd follow the command, external ones are left
+	userTest := filepath.Join(cmdDir, "user_test.go")
+	if err := afero.WriteFile(fs, userTest, []byte("package cmd\n\nimport (\n\t\"testing\"\n)\n\nfunc TestUser(t *testing.T) {\n\tif userCmd.Use != \"user\" {\n\t\tt.Fatal(userCmd.Use)\n\t}\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rootTest := []byte("package cmd_test\n\nimport \"testing\"\n\nfunc TestRoot(t *testing.T) {\n\tuserCmd := 1\n\t_ = userCmd\n}\n")
+	if err := afero.WriteFile(fs, filepath.Join(cmdDir, "root_test.go"), rootTest, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	generator, err := planMove(t, fs, cmdDir, "user", "admin", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat(fs, filepath.Join(cmdDir, "user.go")) {
+		t.Error("user.go was not moved")
+	}
+	assertFileMatchesGolden(t, fs, filepath.Join(cmdDir, "admin", "user.go"), "testdata/move_package.golden")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "admin.go"), "\t\"github.com/acme/myproject/cmd/admin\"\n")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "admin.go"), "\tadminCmd.AddCommand(admin.UserCmd)\n")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "list.go"), "\tadmin.UserCmd.AddCommand(listCmd)\n")
+	assertRegistered(t, fs, userTest, "\t\"github.com/acme/myproject/cmd/admin\"\n")
+	assertRegistered(t, fs, userTest, "\tif admin.UserCmd.Use != \"user\" {\n")
+	if data, err := afero.ReadFile(fs, filepath.Join(cmdDir, "root_test.go")); err != nil || string(data) != string(rootTest) {
+		t.Errorf("root_test.go of package cmd_test was changed:\n%s", data)
+	}
+
+	manifest, err := LoadManifest(fs, generator.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file, ok := manifest.File("cmd/admin/user.go"); !ok || file.Parent != "adminCmd" {
+		t.Errorf("got %+v, want user.go recorded under adminCmd", file)
+	}
+}
+
+func TestMoveGroup(t *testing.T) {
+	viper.Set("license", "none")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	cmdDir := createTestProject(t, fs)
+	addCommand(t, fs, cmdDir, "config", "rootCmd")
+
+	command, err := NewProject([]string{"deploy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	command.SetAbsolutePath(filepath.Join(cmdDir, "deploy"))
+	generator, err := NewProjectGenerator(fs, command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator.Spec.Group, generator.GroupTitle = "ops", "Operations"
+	if err := generator.AddCommandProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the new parent gets the group of the command, titled as before
+	if _, err := planMove(t, fs, cmdDir, "deploy", "config", ""); err != nil {
+		t.Fatal(err)
+	}
+	assertRegistered(t, fs, filepath.Join(cmdDir, "config.go"), "\tconfigCmd.AddGroup(&cobra.Group{ID: \"ops\", Title: \"Operations\"})\n")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "deploy.go"), "\tconfigCmd.AddCommand(deployCmd)\n")
+
+	// a parent adding the group already is left as is
+	before, err := afero.ReadFile(fs, filepath.Join(cmdDir, "root.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := planMove(t, fs, cmdDir, "deploy", "root", ""); err != nil {
+		t.Fatal(err)
+	}
+	if after, err := afero.ReadFile(fs, filepath.Join(cmdDir, "root.go")); err != nil || string(after) != string(before) {
+		t.Errorf("root.go changed:\n%s", after)
+	}
+}
+
+// planMove moves the command name under to, unless planning the move fails.
+func planMove(t *testing.T, fs afero.Fs, cmdDir, name, to, pkgName string) (*Generator, error) {
+	t.Helper()
+
+	project, err := NewProject(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refactoring, err := generator.PlanMove(cmdDir, name, to, pkgName)
+	if err != nil {
+		return nil, err
+	}
+	if err := generator.Refactor(refactoring); err != nil {
+		t.Fatal(err)
+	}
+	return generator, nil
+}
@@ -0,0 +1,327 @@
+package project
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/fs"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// PackManifest is the name of the manifest at the root of a template pack.
+const PackManifest = "pack.yaml"
+
+// DefaultPack is the name of the built-in template pack.
+const DefaultPack = "default"
+
+const (
+	WhenLicensed   = "licensed"
+	WhenUnlicensed = "unlicensed"
+)
+
+// Pack is a named, versioned bundle of templates describing the files of a
+// scaffold, the variables its templates need and the Go modules the
+// generated code depends on.
+type Pack struct {
+	Name         string         `yaml:"name"`
+	Version      string         `yaml:"version"`
+	Description  string         `yaml:"description"`
+	Variables    []PackVariable `yaml:"variables"`
+	Dependencies []string       `yaml:"dependencies"`
+	Files        []PackFile     `yaml:"files"`
+
+	// Source is where the pack was loaded from, FS holds its templates.
+	Source string `yaml:"-"`
+	FS     fs.FS  `yaml:"-"`
+}
+
+// PackVariable is a variable templates of a pack read from .Vars. Required
+// variables without a default must be provided.
+type PackVariable struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Default     string `yaml:"default"`
+	Required    bool   `yaml:"required"`
+}
+
+// PackFile maps a template of a pack to the file it generates. Target is
+// relative to the project and may itself use template actions. When limits
+// the file to licensed or unlicensed projects.
+type PackFile struct {
+	Name     string `yaml:"name"`
+	Template string `yaml:"template"`
+	Target   string `yaml:"target"`
+	When     string `yaml:"when"`
+}
+
+// LoadPack reads the template pack at source, a directory or a .tar.gz
+// archive holding a pack.yaml manifest.
+func LoadPack(afs afero.Fs, source string) (*Pack, error) {
+	info, err := afs.Stat(source)
+	if err != nil {
+		return nil, fmt.Errorf("template pack: %w", err)
+	}
+
+	var packFs afero.Fs
+	switch {
+	case info.IsDir():
+		packFs = afero.NewBasePathFs(afs, source)
+	case strings.HasSuffix(source, ".tar.gz") || strings.HasSuffix(source, ".tgz"):
+		packFs, err = extractPack(afs, source)
+		if err != nil {
+			return nil, fmt.Errorf("template pack %s: %w", source, err)
+		}
+	default:
+		return nil, fmt.Errorf("template pack %s: expected a directory or a .tar.gz archive", source)
+	}
+
+	pack, err := readPack(afero.NewIOFS(packFs), source)
+	if err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// extractPack unpacks a gzipped tar archive into memory. Archives holding a
+// single top-level directory are unpacked from inside that directory.
+func extractPack(afs afero.Fs, source string) (afero.Fs, error) {
+	file, err := afs.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer func(file afero.File) {
+		_ = file.Close()
+	}(file)
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	mem := afero.NewMemMapFs()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean("/" + hdr.Name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := mem.MkdirAll(name, 0755); err != nil {
+				return nil, err
+			}
+		case tar.TypeReg:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			if err := afero.WriteFile(mem, name, data, 0644); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if stat(mem, "/"+PackManifest) {
+		return mem, nil
+	}
+
+	entries, err := afero.ReadDir(mem, "/")
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return afero.NewBasePathFs(mem, "/"+entries[0].Name()), nil
+	}
+	return mem, nil
+}
+
+// defaultPack returns the built-in pack, read from templates so that a
+// templates directory may override both its manifest and its templates.
+func defaultPack(templates fs.FS) (*Pack, error) {
+	sub, err := fs.Sub(templates, templateDir)
+	if err != nil {
+		return nil, err
+	}
+	return readPack(sub, templateDir)
+}
+
+func readPack(packFS fs.FS, source string) (*Pack, error) {
+	data, err := fs.ReadFile(packFS, PackManifest)
+	if err != nil {
+		return nil, fmt.Errorf("template pack %s: %w", source, err)
+	}
+
+	pack := &Pack{}
+	if err := yaml.Unmarshal(data, pack); err != nil {
+		return nil, fmt.Errorf("template pack %s: %s: %w", source, PackManifest, err)
+	}
+
+	pack.Source = source
+	pack.FS = packFS
+	if err := pack.validate(); err != nil {
+		return nil, fmt.Errorf("template pack %s: %w", source, err)
+	}
+	return pack, nil
+}
+
+func (p *Pack) validate() error {
+	if p.Name == "" {
+		return errors.New("manifest has no name")
+	}
+	if len(p.Files) == 0 {
+		return errors.New("manifest lists no files")
+	}
+
+	for i, file := range p.Files {
+		switch {
+		case file.Template == "":
+			return fmt.Errorf("file %d has no template", i)
+		case file.Target == "":
+			return fmt.Errorf("file %s has no target", file.Template)
+		case file.When != "" && file.When != WhenLicensed && file.When != WhenUnlicensed:
+			return fmt.Errorf("file %s: unknown condition %q", file.Template, file.When)
+		}
+
+		if _, err := fs.Stat(p.FS, file.Template); err != nil {
+			return fmt.Errorf("file %s: %w", file.Template, err)
+		}
+	}
+
+	for _, variable := range p.Variables {
+		if variable.Name == "" {
+			return errors.New("manifest has a variable without a name")
+		}
+	}
+	return nil
+}
+
+// String returns the name and version of the pack.
+func (p *Pack) String() string {
+	if p.Version == "" {
+		return p.Name
+	}
+	return p.Name + "@" + p.Version
+}
+
+// resolveVariables fills vars with the defaults of the pack and fails
+// listing every required variable that is still missing.
+func (p *Pack) resolveVariables(vars map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(vars))
+	for name, value := range vars {
+		resolved[name] = value
+	}
+
+	var missing []string
+	for _, variable := range p.Variables {
+		if _, ok := resolved[variable.Name]; ok {
+			continue
+		}
+		if variable.Default != "" || !variable.Required {
+			resolved[variable.Name] = variable.Default
+			continue
+		}
+		missing = append(missing, variable.Name)
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("template pack %s requires variable(s): %s (set with --var name=value)", p, strings.Join(missing, ", "))
+	}
+	return resolved, nil
+}
+
+// getFileContentPack collects a Content for every file of the pack that
+// applies to the Project.
+func (g *Generator) getFileContentPack() error {
+	vars, err := g.Pack.resolveVariables(g.Project.Vars)
+	if err != nil {
+		return err
+	}
+	g.Project.Vars = vars
+
+	for _, file := range g.Pack.Files {
+		if (file.When == WhenLicensed && g.None) || (file.When == WhenUnlicensed && !g.None) {
+			continue
+		}
+
+		target, err := renderPackTarget(file, g.Project)
+		if err != nil {
+			return err
+		}
+
+		content := Content{
+			Name:             file.Name,
+			TemplateFilePath: path.Join(g.Pack.Source, file.Template),
+			FilePath:         filepath.Join(g.Project.AbsolutePath, filepath.FromSlash(target)),
+			Dirty:            true,
+		}
+		if content.Name == "" {
+			content.Name = strings.TrimSuffix(path.Base(file.Template), path.Ext(file.Template))
+		}
+
+		data, err := fs.ReadFile(g.Pack.FS, file.Template)
+		if err != nil {
+			return err
+		}
+
+		content.TemplateContent = string(data)
+		content.Data = g.Project
+		content.Dirty = false
+		g.Content = append(g.Content, content)
+	}
+
+	return nil
+}
+
+// renderPackTarget renders the target path of file, refusing paths that
+// would leave the project directory.
+func renderPackTarget(file PackFile, project *Project) (string, error) {
+	tmpl, err := template.New(file.Template).Funcs(funcMap()).Option("missingkey=error").Parse(file.Target)
+	if err != nil {
+		return "", fmt.Errorf("target of %s: %w", file.Template, err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, withVars(project, referencedVars(tmpl, project))); err != nil {
+		return "", fmt.Errorf("target of %s: %w", file.Template, err)
+	}
+
+	target := path.Clean(sb.String())
+	if path.IsAbs(target) || target == ".." || strings.HasPrefix(target, "../") {
+		return "", fmt.Errorf("target of %s: %s is outside of the project", file.Template, sb.String())
+	}
+	return target, nil
+}
+
+// InstallDependencies adds the Go modules required by the pack to the
+// module of the Project and tidies it.
+func (g *Generator) InstallDependencies() error {
+	for _, dependency := range g.Pack.Dependencies {
+		if err := GoGet(dependency); err != nil {
+			return fmt.Errorf("go get %s: %w", dependency, err)
+		}
+	}
+
+	if len(g.Pack.Dependencies) == 0 {
+		return nil
+	}
+
+	cmd := exec.Command("go", "mod", "tidy")
+	cmd.Stdout = nil
+	cmd.Stderr = nil
+	return cmd.Run()
+}
@@ -0,0 +1,145 @@
+package project
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var daemonPack = map[string]string{
+	"pack.yaml": `name: daemon
+version: 0.1.0
+variables:
+  - name: Port
+    required: true
+dependencies:
+  - github.com/spf13/cobra
+files:
+  - template: main.tmpl
+    target: main.go
+  - name: daemon
+    template: daemon.tmpl
+    target: internal/{{ .AppName }}/daemon.go
+  - template: notice.tmpl
+    target: NOTICE
+    when: licensed
+`,
+	"main.tmpl":   "package main\n\nfunc main() {}\n",
+	"daemon.tmpl": "package {{ .AppName }}\n\nconst Port = {{ .Vars.Port }}\n",
+	"notice.tmpl": "{{ .Legal.Copyright }}\n",
+}
+
+func TestLoadPackDirectory(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for name, data := range daemonPack {
+		if err := afero.WriteFile(fs, filepath.Join("/packs/daemon", name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pack, err := LoadPack(fs, "/packs/daemon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDaemonPack(t, fs, pack)
+}
+
+func TestLoadPackArchive(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range daemonPack {
+		if err := tw.WriteHeader(&tar.Header{Name: "daemon/" + name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "/packs/daemon.tar.gz", buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := LoadPack(fs, "/packs/daemon.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDaemonPack(t, fs, pack)
+}
+
+func assertDaemonPack(t *testing.T, fs afero.Fs, pack *Pack) {
+	t.Helper()
+
+	if pack.String() != "daemon@0.1.0" || len(pack.Dependencies) != 1 {
+		t.Fatalf("unexpected pack: %+v", pack)
+	}
+
+	viper.Set("license", "none")
+	defer viper.Reset()
+
+	project, err := NewProject([]string{"myproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator.Pack = pack
+
+	if err := generator.PrepareModels(); err == nil || !strings.Contains(err.Error(), "Port") {
+		t.Fatalf("expected the missing Port variable to be reported, got %v", err)
+	}
+
+	generator.Content = nil
+	project.Vars = map[string]string{"Port": "8080"}
+	if err := generator.PrepareModels(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := generator.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	for _, entry := range entries {
+		if entry.Name == "manifest" {
+			continue
+		}
+
+		rel, err := filepath.Rel(project.AbsolutePath, entry.FilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[filepath.ToSlash(rel)] = string(entry.Body)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected main.go and the daemon only, got %v", got)
+	}
+	if got["internal/myproject/daemon.go"] != "package myproject\n\nconst Port = 8080\n" {
+		t.Fatalf("unexpected daemon.go: %q", got["internal/myproject/daemon.go"])
+	}
+}
+
+func TestPackTargetOutsideProject(t *testing.T) {
+	_, err := renderPackTarget(PackFile{Template: "x.tmpl", Target: "../{{ .AppName }}.go"}, &Project{AppName: "evil"})
+	if err == nil {
+		t.Fatal("expected a target outside of the project to be refused")
+	}
+}
@@ -0,0 +1,137 @@
+package project
+
+import (
+	"github.com/spf13/afero"
+	"path/filepath"
+	"slices"
+)
+
+const (
+	ActionCreate    = "create"
+	ActionOverwrite = "overwrite"
+	ActionSkip      = "skip"
+	ActionConflict  = "conflict"
+)
+
+// PlanEntry describes what generation would do with a single file.
+type PlanEntry struct {
+	Name     string
+	FilePath string
+	Action   string
+	Size     int64
+	Body     []byte
+}
+
+// Plan renders every prepared Content into an in-memory filesystem and
+// reports, for each target path, whether it would be created or
+// overwritten on the real filesystem, taking the conflict policy into
+// account. Nothing is written to g.Afs.
+func (g *Generator) Plan() ([]PlanEntry, error) {
+	mem := afero.NewMemMapFs()
+	rendered := map[string][]byte{}
+
+	var entries []PlanEntry
+	for _, content := range g.Content {
+		if content.Dirty {
+			continue
+		}
+
+		if err := mem.MkdirAll(filepath.Dir(content.FilePath), 0751); err != nil {
+			return nil, err
+		}
+
+		body, err := g.render(content)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeFileContent(mem, content.FilePath, body); err != nil {
+			return nil, err
+		}
+
+		filePath := content.FilePath
+		action := ActionCreate
+		if stat(g.Afs, content.FilePath) {
+			switch g.OnConflict {
+			case ConflictOverwrite:
+				action = ActionOverwrite
+			case ConflictSkip:
+				action = ActionSkip
+			case ConflictNew:
+				filePath = Conflict{FilePath: filePath, Policy: ConflictNew}.Target()
+				if stat(g.Afs, filePath) {
+					action = ActionOverwrite
+				}
+			default:
+				action = ActionConflict
+			}
+		}
+
+		entries = append(entries, PlanEntry{
+			Name:     content.Name,
+			FilePath: filePath,
+			Action:   action,
+			Size:     int64(len(body)),
+			Body:     body,
+		})
+
+		if filePath == content.FilePath && action != ActionSkip && action != ActionConflict {
+			rendered[filePath] = body
+		}
+	}
+
+	if _, err := g.stageUpdates(mem); err != nil {
+		return nil, err
+	}
+	for _, update := range g.updates {
+		entries = append(entries, PlanEntry{
+			Name:     "update",
+			FilePath: update.FilePath,
+			Action:   ActionOverwrite,
+			Size:     int64(len(update.Data)),
+			Body:     update.Data,
+		})
+	}
+
+	registered, err := g.stageRegistrations(mem, func(content Content) string { return content.FilePath })
+	if err != nil {
+		return nil, err
+	}
+	for _, filePath := range registered {
+		body, err := afero.ReadFile(mem, filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := PlanEntry{Name: "register", FilePath: filePath, Action: ActionOverwrite}
+		i := slices.IndexFunc(entries, func(e PlanEntry) bool { return e.FilePath == filePath })
+		if i < 0 {
+			entries = append(entries, entry)
+			i = len(entries) - 1
+		}
+		entries[i].Size, entries[i].Body = int64(len(body)), body
+	}
+
+	if g.recordsManifest() {
+		body, err := g.manifestFile(rendered)
+		if err != nil {
+			return nil, err
+		}
+
+		manifestPath := filepath.Join(g.Root, ManifestName)
+		action := ActionCreate
+		if stat(g.Afs, manifestPath) {
+			action = ActionOverwrite
+		}
+
+		entries = append(entries, PlanEntry{
+			Name:     "manifest",
+			FilePath: manifestPath,
+			Action:   action,
+			Size:     int64(len(body)),
+			Body:     body,
+		})
+	}
+
+	return entries, nil
+}
@@ -2,7 +2,6 @@ package project
 
 import (
 	"bytes"
-	"crypto/md5"
 	"embed"
 	"encoding/json"
 	"errors"
@@ -10,23 +9,26 @@ import (
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"go/parser"
+	"go/token"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"strings"
 	"text/template"
 	"time"
 	"unicode"
 )
 
-//go:embed tpl/*.tmpl
+//go:embed tpl/*.tmpl tpl/pack.yaml
 var templates embed.FS
 
 func compareContent(contentA, contentB []byte) error {
 	if !bytes.Equal(ensureLF(contentA), ensureLF(contentB)) {
-		return errors.New("byte slices differ")
+		return fmt.Errorf("byte slices differ:\n%s", unifiedDiff("a", "b", contentA, contentB))
 	}
 	return nil
 }
@@ -118,6 +120,8 @@ type Command struct {
 	CmdName          string
 	CmdParent        string
 	ExtractedLicense string
+	Registration     Registration
+	Spec             CommandSpec
 	*Project
 }
 
@@ -128,6 +132,7 @@ type Project struct {
 	AppName      string
 	CmdName      string
 	Legal        *License
+	Vars         map[string]string
 }
 
 func NewProject(args []string) (*Project, error) {
@@ -164,28 +169,62 @@ func (p *Project) SetAbsolutePath(value string) {
 }
 
 type Generator struct {
-	Afs       afero.Fs            `json:"-" yaml:"-"`
-	Templates embed.FS            `json:"-" yaml:"-"`
-	Licenses  map[string]*License `json:"licenses" yaml:"licenses"`
-	None      bool
-	Project   *Project
-	Content   []Content
-}
-
-func NewProjectGenerator(fs afero.Fs, project *Project) (*Generator, error) {
+	Afs          afero.Fs            `json:"-" yaml:"-"`
+	Templates    fs.FS               `json:"-" yaml:"-"`
+	Licenses     map[string]*License `json:"licenses" yaml:"licenses"`
+	None         bool
+	GroupImports bool
+	Parent       string
+	Registration Registration
+	Spec         CommandSpec
+	GroupTitle   string
+	OnConflict   ConflictPolicy
+	Conflicts    []Conflict
+	Pack         *Pack
+	Root         string
+	Manifest     *Manifest
+	Project      *Project
+	Content      []Content
+
+	updates []fileUpdate
+	// initializing is set when a new project is generated, which gets a
+	// manifest; projects generated without one are left without it.
+	initializing bool
+}
+
+func NewProjectGenerator(afs afero.Fs, project *Project) (*Generator, error) {
 	project.CmdName = validateCmdName(project.Args)
 
-	license, ok := contentLicenses(templates)[viper.GetString("license")]
-	if ok {
+	tplFS, err := newTemplateFS(afs, viper.GetString("templates"))
+	if err != nil {
+		return nil, err
+	}
+	if tplFS, err = customLicenseFS(tplFS); err != nil {
+		return nil, err
+	}
+
+	if name := licenseName(); name != "" {
+		license, err := findLicense(contentLicenses(tplFS), name)
+		if err != nil {
+			return nil, err
+		}
 		project.Legal = license
 	}
 
+	pack, err := defaultPack(tplFS)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Generator{
-		None:      project.Legal.Code == "none",
-		Afs:       fs,
-		Templates: templates,
-		Project:   project,
-		Content:   []Content{},
+		None:         project.Legal.Code == "none",
+		Afs:          afs,
+		Templates:    tplFS,
+		Registration: RegisterInit,
+		OnConflict:   ConflictFail,
+		Pack:         pack,
+		Project:      project,
+		Content:      []Content{},
 	}, nil
 }
 
@@ -206,73 +245,57 @@ func (g *Generator) CmdName() string {
 	return g.Project.CmdName
 }
 
+// PrepareModels collects the content of a new project: its LICENSE and
+// every file of the template pack.
 func (g *Generator) PrepareModels() error {
-	if err := g.getFileContentLicense(); err != nil {
-		return err
-	}
-
-	if err := g.getFileContentMain(); err != nil {
-		return err
-	}
+	g.Root = g.Project.AbsolutePath
+	g.initializing = true
 
-	if err := g.getFileContentRoot(); err != nil {
-		return err
-	}
-
-	if err := g.getFileContentConfig(); err != nil {
-		return err
-	}
-
-	if err := g.getFileContentService(); err != nil {
-		return err
-	}
-
-	if err := g.getFileContentIgnore(); err != nil {
+	if err := g.getFileContentLicense(); err != nil {
 		return err
 	}
 
-	if err := g.getFileContentReadme(); err != nil {
+	if err := g.getFileContentPack(); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// CreateProject sets up the Project structure and files.
+// CreateProject sets up the Project structure and files. Every file is
+// rendered before anything is written; if a later step fails, the created
+// directories, written files and go.mod are rolled back and a
+// *RollbackError describing the undone changes is returned.
 func (g *Generator) CreateProject() error {
 	if g.Project.Legal == nil {
 		return errors.New("no legal Project")
 	}
 
-	// Ensure base directory exists
-	if !stat(g.Afs, g.Project.AbsolutePath) {
-		if err := g.Afs.MkdirAll(g.Project.AbsolutePath, 0754); err != nil {
-			return err
-		}
+	// Refuse early, before any directory or go.mod is created
+	if err := g.resolveConflicts(); err != nil {
+		return err
 	}
 
-	rootPath := filepath.Join(g.Project.AbsolutePath, "cmd")
-	if !stat(g.Afs, rootPath) {
-		if err := g.Afs.MkdirAll(rootPath, 0751); err != nil {
-			return err
-		}
+	files, err := g.stage()
+	if err != nil {
+		return err
 	}
 
-	configPath := filepath.Join(g.Project.AbsolutePath, "internal", "config")
-	if !stat(g.Afs, configPath) {
-		if err := g.Afs.MkdirAll(configPath, 0751); err != nil {
-			return err
-		}
+	tx := newTransaction(g.Afs)
+	if err := g.createProject(tx, files); err != nil {
+		return tx.rollback(err)
 	}
 
-	servicePath := filepath.Join(g.Project.AbsolutePath, "internal", "service")
-	if !stat(g.Afs, servicePath) {
-		if err := g.Afs.MkdirAll(servicePath, 0751); err != nil {
-			return err
-		}
+	return nil
+}
+
+func (g *Generator) createProject(tx *transaction, files *staged) error {
+	// Ensure base directory exists, the others follow the generated files
+	if err := tx.mkdirAll(g.Project.AbsolutePath, 0754); err != nil {
+		return err
 	}
 
-	if err := g.goModInit(); err != nil {
+	if err := g.goModInit(tx); err != nil {
 		return err
 	}
 
@@ -280,6 +303,15 @@ func (g *Generator) CreateProject() error {
 	// 	return err
 	// }
 
+	return files.commit(tx)
+}
+
+// AddCommandProject sets up the Project structure and files for a new command.
+func (g *Generator) AddCommandProject() error {
+	if err := g.PrepareCommandModels(); err != nil {
+		return err
+	}
+
 	if err := g.renderTemplate(); err != nil {
 		return err
 	}
@@ -287,16 +319,26 @@ func (g *Generator) CreateProject() error {
 	return nil
 }
 
-// AddCommandProject sets up the Project structure and files for a new command.
-func (g *Generator) AddCommandProject() error {
-	// find LICENSE and root.go file in project
-	_, rootGo, err := findLicenseAndRootGo(g.Afs, g.Project.AbsolutePath)
-	if err != nil {
-		return err
+// PrepareCommandModels locates the root command of the Project and collects
+// the content needed to add a new command, without writing anything. A
+// path such as config/create/user also collects the content of every
+// missing intermediate command.
+func (g *Generator) PrepareCommandModels() error {
+	names := commandPath(g.Project.Args)
+	if len(names) == 0 {
+		names = []string{g.Project.AppName}
 	}
 
-	if !stat(g.Afs, rootGo) {
-		return fmt.Errorf("no root file found on: %s", rootGo)
+	// nested commands are generated next to the others, search from where
+	// a single command would have been
+	dir := g.Project.AbsolutePath
+	for range names[1:] {
+		dir = filepath.Dir(dir)
+	}
+
+	rootGo, err := g.locateRootGo(filepath.Dir(dir))
+	if err != nil {
+		return err
 	}
 
 	g.Project.AbsolutePath = filepath.Dir(rootGo)
@@ -308,37 +350,72 @@ func (g *Generator) AddCommandProject() error {
 		}
 	}
 
-	if err := g.getFileContentSub(rootGo); err != nil {
-		return err
+	return g.getFileContentSub(rootGo, names)
+}
+
+// locateRootGo returns the root.go of the project holding dir and sets the
+// project Root. The manifest marks the root, older projects are searched
+// for their LICENSE and root.go files from dir.
+func (g *Generator) locateRootGo(dir string) (string, error) {
+	if err := g.loadProjectManifest(dir); err != nil {
+		return "", err
 	}
 
-	if err := g.renderTemplate(); err != nil {
-		return err
+	rootGo := filepath.Join(g.Root, "cmd", "root.go")
+	if g.Manifest == nil {
+		var err error
+		_, rootGo, err = findLicenseAndRootGo(g.Afs, dir)
+		if err != nil {
+			return "", err
+		}
+		g.Root = filepath.Dir(filepath.Dir(rootGo))
 	}
 
-	return nil
+	if !stat(g.Afs, rootGo) {
+		return "", fmt.Errorf("no root file found on: %s", rootGo)
+	}
+	return rootGo, nil
 }
 
-func (g *Generator) goModInit() error {
-	if _, err := os.Stat("go.mod"); err != nil {
-		modName := g.Project.PkgName
+// goModInit runs `go mod init` in the Project directory unless it, or one
+// of its parents, already holds a go.mod.
+func (g *Generator) goModInit(tx *transaction) error {
+	if findGoMod(g.Project.AbsolutePath) != "" {
+		return nil
+	}
 
-		if modName == "" {
-			wd, err := os.Getwd()
-			if err != nil {
-				return err
-			}
-			modName = path.Base(wd)
-		}
+	modName := g.Project.PkgName
+	if modName == "" {
+		modName = path.Base(g.Project.AbsolutePath)
+	}
 
-		cmd := exec.Command("go", "mod", "init", modName)
-		cmd.Stdout = nil
-		cmd.Stderr = nil
-		return cmd.Run()
+	cmd := exec.Command("go", "mod", "init", modName)
+	cmd.Dir = g.Project.AbsolutePath
+	cmd.Stdout = nil
+	cmd.Stderr = nil
+
+	// journal first, a failing `go mod init` may still leave a go.mod behind
+	tx.created(filepath.Join(g.Project.AbsolutePath, "go.mod"))
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go mod init %s: %w", modName, err)
 	}
 	return nil
 }
 
+// findGoMod returns the path of the go.mod governing dir, looking in dir
+// and its parents, or "" when there is none.
+func findGoMod(dir string) string {
+	for dir = filepath.Clean(dir); ; dir = filepath.Dir(dir) {
+		modFile := filepath.Join(dir, "go.mod")
+		if _, err := os.Stat(modFile); err == nil {
+			return modFile
+		}
+		if dir == filepath.Dir(dir) {
+			return ""
+		}
+	}
+}
+
 func (g *Generator) gitInit() error {
 	if _, err := os.Stat(".git"); err != nil {
 		if err := exec.Command("git", "init").Run(); err != nil {
@@ -353,33 +430,6 @@ func (g *Generator) gitInit() error {
 	return nil
 }
 
-func (g *Generator) getFileContentMain() error {
-	content := Content{
-		Name:             "main",
-		TemplateFilePath: "tpl/main.tmpl",
-		FilePath:         fmt.Sprintf("%s/main.go", g.Project.AbsolutePath),
-		Dirty:            true,
-	}
-
-	if g.None {
-		content.TemplateFilePath = "tpl/main_none.tmpl"
-	}
-
-	defer func() {
-		g.Content = append(g.Content, content)
-	}()
-
-	data, err := g.Templates.ReadFile(content.TemplateFilePath)
-	if err != nil {
-		return err
-	}
-
-	content.TemplateContent = string(data)
-	content.Data = g.Project
-	content.Dirty = false
-	return nil
-}
-
 func (g *Generator) getFileContentLicense() error {
 	content := Content{
 		Name:             "license",
@@ -392,8 +442,9 @@ func (g *Generator) getFileContentLicense() error {
 		g.Content = append(g.Content, content)
 	}()
 
-	if !g.None {
-		data, err := g.Templates.ReadFile(content.TemplateFilePath)
+	// a license known only by its SPDX identifier has no text to write
+	if !g.None && g.Project.Legal.Body != "" {
+		data, err := fs.ReadFile(g.Templates, content.TemplateFilePath)
 		if err != nil {
 			return err
 		}
@@ -406,212 +457,210 @@ func (g *Generator) getFileContentLicense() error {
 	return nil
 }
 
-func (g *Generator) getFileContentRoot() error {
-	content := Content{
-		Name:             "root",
-		TemplateFilePath: "tpl/root.tmpl",
-		FilePath:         fmt.Sprintf("%s/cmd/root.go", g.Project.AbsolutePath),
-		Dirty:            true,
-	}
-
-	if g.None {
-		content.TemplateFilePath = "tpl/root_none.tmpl"
-	}
-
-	defer func() {
-		g.Content = append(g.Content, content)
-	}()
-
-	data, err := g.Templates.ReadFile(content.TemplateFilePath)
+// getFileContentSub collects a Content for every command of names that is
+// not declared yet, each registered to the one before it and the first to
+// the Parent command, which must be declared in the cmd package.
+func (g *Generator) getFileContentSub(rootGo string, names []string) error {
+	tmpl, err := g.commandTemplate(rootGo)
 	if err != nil {
 		return err
 	}
 
-	content.TemplateContent = string(data)
-	content.Data = g.Project
-	content.Dirty = false
-	return nil
-}
-
-func (g *Generator) getFileContentConfig() error {
-	content1 := Content{
-		Name:             "config",
-		TemplateFilePath: "tpl/config.tmpl",
-		FilePath:         fmt.Sprintf("%s/internal/config/config.go", g.Project.AbsolutePath),
-		Dirty:            true,
-	}
-
-	content2 := Content{
-		Name:             "config_test",
-		TemplateFilePath: "tpl/config_test.tmpl",
-		FilePath:         fmt.Sprintf("%s/internal/config/config_test.go", g.Project.AbsolutePath),
-		Dirty:            true,
-	}
-
-	content3 := Content{
-		Name:             "config_custom",
-		TemplateFilePath: "tpl/custom.tmpl",
-		FilePath:         fmt.Sprintf("%s/internal/config/custom.go", g.Project.AbsolutePath),
-		Dirty:            true,
-	}
-
-	defer func() {
-		g.Content = append(g.Content, content1)
-		g.Content = append(g.Content, content2)
-		g.Content = append(g.Content, content3)
-	}()
-
-	data1, err := g.Templates.ReadFile(content1.TemplateFilePath)
+	pkg, err := parseCommandPackage(g.Afs, g.Project.AbsolutePath)
 	if err != nil {
 		return err
 	}
 
-	data2, err := g.Templates.ReadFile(content2.TemplateFilePath)
-	if err != nil {
-		return err
+	parent := g.Parent
+	if parent == "" {
+		parent = "rootCmd"
 	}
-
-	data3, err := g.Templates.ReadFile(content3.TemplateFilePath)
-	if err != nil {
+	if err := pkg.requireVar(parent); err != nil {
 		return err
 	}
 
-	content1.TemplateContent = string(data1)
-	content1.Data = g.Project
-	content1.Dirty = false
+	for i, name := range names {
+		cmdName := validateCmdName([]string{name})
+		filePath := filepath.Join(g.Project.AbsolutePath, name+".go")
 
-	content2.TemplateContent = string(data2)
-	content2.Data = g.Project
-	content2.Dirty = false
+		if declared, ok := pkg.vars[cmdName+"Cmd"]; ok {
+			if i == len(names)-1 && declared != filePath {
+				return fmt.Errorf("command %sCmd is already declared in %s", cmdName, declared)
+			}
+			if i < len(names)-1 {
+				// reused only where the path puts it
+				if registered := pkg.parent(cmdName + "Cmd"); registered != parent {
+					return unexpectedParent(cmdName+"Cmd", registered, parent)
+				}
+				parent = cmdName + "Cmd"
+				continue
+			}
+		}
 
-	content3.TemplateContent = string(data3)
-	content3.Data = g.Project
-	content3.Dirty = false
+		command := Command{
+			CmdParent:        parent,
+			CmdName:          cmdName,
+			Project:          g.Project,
+			ExtractedLicense: tmpl.license,
+			Registration:     g.Registration,
+		}
+		// flags, args and aliases belong to the command asked for
+		if i == len(names)-1 {
+			command.Spec = g.Spec
+			if err := g.addParentGroup(pkg, parent); err != nil {
+				return err
+			}
+		}
+
+		g.Content = append(g.Content, tmpl.content(filePath, command))
+
+		parent = cmdName + "Cmd"
+		g.Project.CmdName = cmdName
+	}
 	return nil
 }
 
-func (g *Generator) getFileContentService() error {
-	content := Content{
-		Name:             "service",
-		TemplateFilePath: "tpl/service.tmpl",
-		FilePath:         fmt.Sprintf("%s/internal/service/service.go", g.Project.AbsolutePath),
-		Dirty:            true,
+// unexpectedParent returns the error of an existing command met on the
+// path of a new one, registered to registered rather than to parent.
+func unexpectedParent(cmdVar, registered, parent string) error {
+	if registered == "" {
+		return fmt.Errorf("command %s is not registered, it cannot be reused under %s", cmdVar, parent)
 	}
+	return fmt.Errorf("command %s is registered under %s, not %s", cmdVar, registered, parent)
+}
 
-	defer func() {
-		g.Content = append(g.Content, content)
-	}()
+// addParentGroup makes parent add the group of Spec when it does not yet,
+// titled GroupTitle or else after its ID: the parent generated along gets
+// it in its Spec, an existing one in the init() of the file declaring it.
+func (g *Generator) addParentGroup(pkg *commandPackage, parent string) error {
+	if g.Spec.Group == "" {
+		return nil
+	}
 
-	data, err := g.Templates.ReadFile(content.TemplateFilePath)
-	if err != nil {
-		return err
+	group := Group{ID: g.Spec.Group, Title: g.GroupTitle}
+	if group.Title == "" {
+		group.Title = exportName(group.ID) + " Commands"
 	}
 
-	content.TemplateContent = string(data)
-	content.Data = g.Project
-	content.Dirty = false
-	return nil
-}
+	for i, content := range g.Content {
+		if command, ok := content.Data.(Command); ok && command.CmdName+"Cmd" == parent {
+			if !slices.ContainsFunc(command.Spec.Groups, func(other Group) bool { return other.ID == group.ID }) {
+				command.Spec.Groups = append(command.Spec.Groups, group)
+				g.Content[i].Data = command
+			}
+			return nil
+		}
+	}
 
-func (g *Generator) getFileContentIgnore() error {
-	content := Content{
-		Name:             "gitignore",
-		TemplateFilePath: "tpl/gitignore.tmpl",
-		FilePath:         fmt.Sprintf("%s/.gitignore", g.Project.AbsolutePath),
-		Dirty:            true,
+	if slices.Contains(pkg.groups(parent), group.ID) {
+		return nil
 	}
+	return g.updateFile(pkg.vars[parent], func(src []byte, filename string) ([]byte, error) {
+		return addGroups(src, filename, parent, []Group{group})
+	})
+}
 
-	defer func() {
-		g.Content = append(g.Content, content)
-	}()
+// commandTemplate is the template of the commands of a project and the
+// license header it is rendered with.
+type commandTemplate struct {
+	path    string
+	text    string
+	license string
+}
+
+// commandTemplate returns the template generating commands next to rootGo:
+// add_command.tmpl with the license comment of rootGo, or else with the
+// header of the license identified from the LICENSE file of the project,
+// or add_command_none.tmpl when neither is found.
+func (g *Generator) commandTemplate(rootGo string) (commandTemplate, error) {
+	tmpl := commandTemplate{path: "tpl/add_command.tmpl"}
 
-	data, err := g.Templates.ReadFile(content.TemplateFilePath)
+	comment, err := extractBlockCommentBeforePackage(g.Afs, rootGo)
 	if err != nil {
-		return err
+		return tmpl, err
 	}
 
-	content.TemplateContent = string(data)
-	content.Data = g.Project
-	content.Dirty = false
-	return nil
-}
-
-func (g *Generator) getFileContentReadme() error {
-	content := Content{
-		Name:             "readme",
-		TemplateFilePath: "tpl/readme.tmpl",
-		FilePath:         fmt.Sprintf("%s/README.md", g.Project.AbsolutePath),
-		Dirty:            true,
+	if comment == "" {
+		match, err := g.DetectLicense(filepath.Dir(rootGo))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return tmpl, err
+		}
+		if match != nil && match.Confident() {
+			comment = match.Comment()
+		}
 	}
 
-	defer func() {
-		g.Content = append(g.Content, content)
-	}()
+	if comment == "" {
+		g.None = true
+		tmpl.path = "tpl/add_command_none.tmpl"
+	}
+	tmpl.license = comment
 
-	data, err := g.Templates.ReadFile(content.TemplateFilePath)
+	data, err := fs.ReadFile(g.Templates, tmpl.path)
 	if err != nil {
-		return err
+		return tmpl, err
 	}
-
-	content.TemplateContent = string(data)
-	content.Data = g.Project
-	content.Dirty = false
-	return nil
+	tmpl.text = string(data)
+	return tmpl, nil
 }
 
-func (g *Generator) getFileContentSub(rootGo string) error {
-	content := Content{
+func (t commandTemplate) content(filePath string, command Command) Content {
+	return Content{
 		Name:             "add_command",
-		FilePath:         fmt.Sprintf("%s/%s.go", g.Project.AbsolutePath, g.Project.AppName),
-		TemplateFilePath: "tpl/add_command.tmpl",
-		Dirty:            true,
+		FilePath:         filePath,
+		TemplateFilePath: t.path,
+		TemplateContent:  t.text,
+		Data:             command,
 	}
+}
 
-	comment, err := extractBlockCommentBeforePackage(g.Afs, rootGo)
+func (g *Generator) renderTemplate() error {
+	if err := g.resolveConflicts(); err != nil {
+		return err
+	}
+
+	files, err := g.stage()
 	if err != nil {
 		return err
 	}
 
-	if comment == "" {
-		g.None = true
-		content.TemplateFilePath = "tpl/add_command_none.tmpl"
+	tx := newTransaction(g.Afs)
+	if err := files.commit(tx); err != nil {
+		return tx.rollback(err)
 	}
+	return nil
+}
 
-	defer func() {
-		g.Content = append(g.Content, content)
-	}()
+// render executes the template of content. Go sources are run through
+// gofmt and, with GroupImports set, get their imports grouped.
+func (g *Generator) render(content Content) ([]byte, error) {
+	if err := checkTemplateFields(content); err != nil {
+		return nil, err
+	}
 
-	data, err := g.Templates.ReadFile(content.TemplateFilePath)
+	data, err := renderContent(content)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("render %s (template %s): %w", content.Name, content.TemplateFilePath, err)
 	}
 
-	content.TemplateContent = string(data)
-	content.Data = Command{
-		CmdParent:        "rootCmd",
-		CmdName:          g.Project.CmdName,
-		Project:          g.Project,
-		ExtractedLicense: comment,
+	if filepath.Ext(content.FilePath) != ".go" {
+		return data, nil
 	}
-	content.Dirty = false
-	return nil
-}
 
-func (g *Generator) renderTemplate() error {
-	for _, content := range g.Content {
-		if content.Dirty {
-			continue
-		}
+	var modulePath string
+	if g.GroupImports {
+		modulePath = g.Project.PkgName
+	}
 
-		if err := renderFileContent(g.Afs, content); err != nil {
-			return err
-		}
+	formatted, err := formatGoSource(data, modulePath)
+	if err != nil {
+		return nil, formatError(content, data, err)
 	}
-	return nil
+	return formatted, nil
 }
 
-func renderFileContent(afs afero.Fs, content Content) error {
-	file, err := afs.Create(content.FilePath)
+func writeFileContent(afs afero.Fs, filePath string, data []byte) error {
+	file, err := afs.Create(filePath)
 	if err != nil {
 		return err
 	}
@@ -621,12 +670,22 @@ func renderFileContent(afs afero.Fs, content Content) error {
 		}
 	}(file)
 
-	tmpl, err := template.New(content.Name).Parse(content.TemplateContent)
+	_, err = file.Write(data)
+	return err
+}
+
+// renderContent executes the template of content and returns the result.
+func renderContent(content Content) ([]byte, error) {
+	tmpl, err := template.New(content.Name).Funcs(funcMap()).Option("missingkey=error").Parse(content.TemplateContent)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return tmpl.Execute(file, content.Data)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, withVars(content.Data, referencedVars(tmpl, content.Data))); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 func stat(afs afero.Fs, namePath string) bool {
@@ -638,6 +697,7 @@ func stat(afs afero.Fs, namePath string) bool {
 
 type License struct {
 	Code            string   // The Code name of the license
+	SPDX            string   // The SPDX identifier of the license
 	Name            string   // The type of license in use
 	PossibleMatches []string // Similar names to guess
 	Header          string   // License header for source files
@@ -646,15 +706,16 @@ type License struct {
 	HashLicense     string   // HashLicense for quick search
 }
 
-func contentLicenses(templates embed.FS) map[string]*License {
+func contentLicenses(templates fs.FS) map[string]*License {
 	year := viper.GetString("year")
 	if year == "" {
 		year = time.Now().Format("2006")
 	}
 
-	return map[string]*License{
+	licenses := map[string]*License{
 		"apache2": {
 			Code:            "apache_2",
+			SPDX:            "Apache-2.0",
 			Name:            "Apache 2.0",
 			PossibleMatches: []string{"Apache-2.0", "apache", "apache20", "apache 2.0", "apache2.0", "apache-2.0"},
 			Header:          getLicenseHeader(templates, "apache_2"),
@@ -664,6 +725,7 @@ func contentLicenses(templates embed.FS) map[string]*License {
 		},
 		"mit": {
 			Code:            "mit",
+			SPDX:            "MIT",
 			Name:            "MIT License",
 			PossibleMatches: []string{"MIT", "mit"},
 			Header:          getLicenseHeader(templates, "mit"),
@@ -673,6 +735,7 @@ func contentLicenses(templates embed.FS) map[string]*License {
 		},
 		"bsd3": {
 			Code:            "bsd_clause_3",
+			SPDX:            "BSD-3-Clause",
 			Name:            "NewBSD",
 			PossibleMatches: []string{"BSD-3-Clause", "bsd", "newbsd", "3 clause bsd", "3-clause bsd"},
 			Header:          getLicenseHeader(templates, "bsd_clause_3"),
@@ -682,6 +745,7 @@ func contentLicenses(templates embed.FS) map[string]*License {
 		},
 		"bsd2": {
 			Code:            "bsd_clause_2",
+			SPDX:            "BSD-2-Clause",
 			Name:            "Simplified BSD License",
 			PossibleMatches: []string{"BSD-2-Clause", "freebsd", "simpbsd", "simple bsd", "2-clause bsd", "2 clause bsd", "simplified bsd license"},
 			Header:          getLicenseHeader(templates, "bsd_clause_2"),
@@ -691,6 +755,7 @@ func contentLicenses(templates embed.FS) map[string]*License {
 		},
 		"gpl2": {
 			Code:            "gpl_2",
+			SPDX:            "GPL-2.0-or-later",
 			Name:            "GNU General Public License 2.0",
 			PossibleMatches: []string{"GPL-2.0", "gpl2", "gnu gpl2", "gplv2"},
 			Header:          getLicenseHeader(templates, "gpl_2"),
@@ -700,6 +765,7 @@ func contentLicenses(templates embed.FS) map[string]*License {
 		},
 		"gpl3": {
 			Code:            "gpl_3",
+			SPDX:            "GPL-3.0-or-later",
 			Name:            "GNU General Public License 3.0",
 			PossibleMatches: []string{"GPL-3.0", "gpl3", "gplv3", "gpl", "gnu gpl3", "gnu gpl"},
 			Header:          getLicenseHeader(templates, "gpl_3"),
@@ -709,6 +775,7 @@ func contentLicenses(templates embed.FS) map[string]*License {
 		},
 		"lgpl": {
 			Code:            "lgpl",
+			SPDX:            "LGPL-3.0-or-later",
 			Name:            "GNU Lesser General Public License",
 			PossibleMatches: []string{"LGPL-3.0", "lgpl", "lesser gpl", "gnu lgpl"},
 			Header:          getLicenseHeader(templates, "lgpl"),
@@ -718,6 +785,7 @@ func contentLicenses(templates embed.FS) map[string]*License {
 		},
 		"agpl": {
 			Code:            "agpl",
+			SPDX:            "AGPL-3.0-or-later",
 			Name:            "GNU Affero General Public License",
 			PossibleMatches: []string{"AGPL-3.0", "agpl", "affero gpl", "gnu agpl"},
 			Header:          getLicenseHeader(templates, "agpl"),
@@ -725,6 +793,66 @@ func contentLicenses(templates embed.FS) map[string]*License {
 			Copyright:       fmt.Sprintf("Copyright © %s %s", year, viper.GetString("author")),
 			HashLicense:     hashLicenseContent(templates, "agpl"),
 		},
+		"mpl2": {
+			Code:            "mpl_2",
+			SPDX:            "MPL-2.0",
+			Name:            "Mozilla Public License 2.0",
+			PossibleMatches: []string{"MPL-2.0", "mpl", "mpl2", "mozilla", "mozilla public license"},
+			Header:          getLicenseHeader(templates, "mpl_2"),
+			Body:            getLicenseBody(templates, "mpl_2"),
+			Copyright:       fmt.Sprintf("Copyright © %s %s", year, viper.GetString("author")),
+			HashLicense:     hashLicenseContent(templates, "mpl_2"),
+		},
+		"isc": {
+			Code:            "isc",
+			SPDX:            "ISC",
+			Name:            "ISC License",
+			PossibleMatches: []string{"ISC", "isc"},
+			Header:          getLicenseHeader(templates, "isc"),
+			Body:            getLicenseBody(templates, "isc"),
+			Copyright:       fmt.Sprintf("Copyright © %s %s", year, viper.GetString("author")),
+			HashLicense:     hashLicenseContent(templates, "isc"),
+		},
+		"unlicense": {
+			Code:            "unlicense",
+			SPDX:            "Unlicense",
+			Name:            "The Unlicense",
+			PossibleMatches: []string{"Unlicense", "unlicense", "public domain"},
+			Header:          getLicenseHeader(templates, "unlicense"),
+			Body:            getLicenseBody(templates, "unlicense"),
+			Copyright:       fmt.Sprintf("Copyright © %s %s", year, viper.GetString("author")),
+			HashLicense:     hashLicenseContent(templates, "unlicense"),
+		},
+		"epl2": {
+			Code:            "epl_2",
+			SPDX:            "EPL-2.0",
+			Name:            "Eclipse Public License 2.0",
+			PossibleMatches: []string{"EPL-2.0", "epl", "epl2", "eclipse", "eclipse public license"},
+			Header:          getLicenseHeader(templates, "epl_2"),
+			Body:            getLicenseBody(templates, "epl_2"),
+			Copyright:       fmt.Sprintf("Copyright © %s %s", year, viper.GetString("author")),
+			HashLicense:     hashLicenseContent(templates, "epl_2"),
+		},
+		"bsl1": {
+			Code:            "bsl_1",
+			SPDX:            "BSL-1.0",
+			Name:            "Boost Software License 1.0",
+			PossibleMatches: []string{"BSL-1.0", "boost", "bsl", "boost software license"},
+			Header:          getLicenseHeader(templates, "bsl_1"),
+			Body:            getLicenseBody(templates, "bsl_1"),
+			Copyright:       fmt.Sprintf("Copyright © %s %s", year, viper.GetString("author")),
+			HashLicense:     hashLicenseContent(templates, "bsl_1"),
+		},
+		"0bsd": {
+			Code:            "0bsd",
+			SPDX:            "0BSD",
+			Name:            "BSD Zero Clause License",
+			PossibleMatches: []string{"0BSD", "zero clause bsd", "0-clause bsd", "0 clause bsd"},
+			Header:          getLicenseHeader(templates, "0bsd"),
+			Body:            getLicenseBody(templates, "0bsd"),
+			Copyright:       fmt.Sprintf("Copyright © %s %s", year, viper.GetString("author")),
+			HashLicense:     hashLicenseContent(templates, "0bsd"),
+		},
 		"none": {
 			Code:            "none",
 			Name:            "None License",
@@ -732,26 +860,39 @@ func contentLicenses(templates embed.FS) map[string]*License {
 			Copyright:       fmt.Sprintf("Copyright © %s %s", year, viper.GetString("author")),
 		},
 	}
+
+	if _, err := fs.Stat(templates, "tpl/license_"+customLicenseCode+".tmpl"); err == nil {
+		licenses[customLicenseCode] = &License{
+			Code:        customLicenseCode,
+			SPDX:        "LicenseRef-" + customLicenseCode,
+			Name:        "Custom License",
+			Header:      getLicenseHeader(templates, customLicenseCode),
+			Body:        getLicenseBody(templates, customLicenseCode),
+			Copyright:   fmt.Sprintf("Copyright © %s %s", year, viper.GetString("author")),
+			HashLicense: hashLicenseContent(templates, customLicenseCode),
+		}
+	}
+	return licenses
 }
 
-func hashLicenseContent(templates embed.FS, code string) string {
-	data, err := templates.ReadFile(fmt.Sprintf("tpl/license_%s.tmpl", code))
+func hashLicenseContent(templates fs.FS, code string) string {
+	data, err := fs.ReadFile(templates, fmt.Sprintf("tpl/license_%s.tmpl", code))
 	if err != nil {
 		return "invalid hash"
 	}
-	return fmt.Sprintf("%X", md5.Sum(data))
+	return hashLicenseWords(licenseWords(string(data)))
 }
 
-func getLicenseHeader(templates embed.FS, code string) string {
-	data, err := templates.ReadFile(fmt.Sprintf("tpl/header_%s.tmpl", code))
+func getLicenseHeader(templates fs.FS, code string) string {
+	data, err := fs.ReadFile(templates, fmt.Sprintf("tpl/header_%s.tmpl", code))
 	if err != nil {
 		return "No header license content"
 	}
 	return string(data)
 }
 
-func getLicenseBody(templates embed.FS, code string) string {
-	data, err := templates.ReadFile(fmt.Sprintf("tpl/license_%s.tmpl", code))
+func getLicenseBody(templates fs.FS, code string) string {
+	data, err := fs.ReadFile(templates, fmt.Sprintf("tpl/license_%s.tmpl", code))
 	if err != nil {
 		return "No license content"
 	}
@@ -761,8 +902,6 @@ func getLicenseBody(templates embed.FS, code string) string {
 func findLicenseAndRootGo(fs afero.Fs, root string) (string, string, error) {
 	var licensePath, rootGoPath string
 
-	root = filepath.Join(root, "..")
-
 	err := afero.Walk(fs, root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -790,18 +929,22 @@ func findLicenseAndRootGo(fs afero.Fs, root string) (string, string, error) {
 	return licensePath, rootGoPath, nil
 }
 
+// extractBlockCommentBeforePackage returns the text of the license header
+// of the Go file at filePath, "" when it has none. See headerComment.
 func extractBlockCommentBeforePackage(fs afero.Fs, filePath string) (string, error) {
 	content, err := afero.ReadFile(fs, filePath)
 	if err != nil {
 		return "", err
 	}
 
-	re := regexp.MustCompile(`(?s)/\*.*?\*/\s*package\s+cmd`)
-	match := re.Find(content)
-	if match == nil {
-		return "", nil
+	file, err := parser.ParseFile(token.NewFileSet(), filePath, content, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return "", err
 	}
 
-	block := regexp.MustCompile(`(?s)/\*.*?\*/`).Find(match)
-	return strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(string(block), "/*", ""), "*/", "")), nil
+	comment := headerComment(file)
+	if comment == nil {
+		return "", nil
+	}
+	return commentText(comment), nil
 }
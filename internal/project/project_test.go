@@ -14,6 +14,7 @@ func TestGenerateRoot(t *testing.T) {
 	viper.SetDefault("author", "NAME HERE <EMAIL ADDRESS>")
 	viper.SetDefault("license", "apache2")
 	viper.SetDefault("projectName", "testApp")
+	viper.Set("year", "2025")
 	defer viper.Reset()
 
 	project, err := NewProject([]string{"myproject"})
@@ -112,6 +113,73 @@ func TestGenerateSub(t *testing.T) {
 	)
 }
 
+func TestPlan(t *testing.T) {
+	viper.SetDefault("author", "NAME HERE <EMAIL ADDRESS>")
+	viper.SetDefault("license", "apache2")
+	viper.Set("year", "2025")
+	defer viper.Reset()
+
+	project, err := NewProject([]string{"myproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project.SetPkgName("github.com/acme/myproject")
+
+	fs := afero.NewMemMapFs()
+	rootGo := filepath.Join(project.AbsolutePath, "cmd", "root.go")
+	if err := afero.WriteFile(fs, rootGo, []byte("package cmd\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := generator.PrepareModels(); err != nil {
+		t.Fatal(err)
+	}
+
+	generator.OnConflict = ConflictOverwrite
+	entries, err := generator.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every content, followed by the manifest
+	if len(entries) != len(generator.Content)+1 || entries[len(entries)-1].Name != "manifest" {
+		t.Fatalf("expected %d plan entries, got %d", len(generator.Content)+1, len(entries))
+	}
+
+	for _, entry := range entries {
+		want := ActionCreate
+		if entry.FilePath == rootGo {
+			want = ActionOverwrite
+		}
+		if entry.Action != want {
+			t.Errorf("%s: expected action %q, got %q", entry.FilePath, want, entry.Action)
+		}
+		if entry.Size != int64(len(entry.Body)) || entry.Size == 0 {
+			t.Errorf("%s: unexpected size %d", entry.FilePath, entry.Size)
+		}
+	}
+
+	// nothing but the pre-existing root.go may exist on the target fs
+	mainGo := filepath.Join(project.AbsolutePath, "main.go")
+	if exists, _ := afero.Exists(fs, mainGo); exists {
+		t.Fatalf("dry run wrote %s", mainGo)
+	}
+
+	data, err := afero.ReadFile(fs, rootGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "package cmd\n" {
+		t.Fatalf("dry run modified %s", rootGo)
+	}
+}
+
 func assertFileMatchesGolden(t *testing.T, fs afero.Fs, filePath string, goldenPath string) {
 	t.Helper()
 
@@ -0,0 +1,110 @@
+package project
+
+import (
+	"path/filepath"
+	"slices"
+)
+
+const (
+	ChangeCreate = "create"
+	ChangeUpdate = "update"
+	ChangeRemove = "remove"
+)
+
+// SourceChange is a change to a file of a project. Data is the new content
+// of the file, nil when it is removed.
+type SourceChange struct {
+	Action   string
+	FilePath string
+	Data     []byte
+}
+
+// Refactoring is the set of changes that removing, renaming or moving
+// commands makes to a project, computed without writing anything.
+type Refactoring struct {
+	Changes []SourceChange
+
+	manifest *Manifest
+}
+
+func (r *Refactoring) change(action, filePath string, data []byte) {
+	i := slices.IndexFunc(r.Changes, func(change SourceChange) bool { return change.FilePath == filePath })
+	if i < 0 {
+		r.Changes = append(r.Changes, SourceChange{Action: action, FilePath: filePath, Data: data})
+		return
+	}
+	r.Changes[i].Data = data
+}
+
+func (r *Refactoring) update(filePath string, data []byte) {
+	r.change(ChangeUpdate, filePath, data)
+}
+
+func (r *Refactoring) create(filePath string, data []byte) {
+	r.change(ChangeCreate, filePath, data)
+}
+
+func (r *Refactoring) remove(filePath string) {
+	r.change(ChangeRemove, filePath, nil)
+}
+
+// manifestCopy returns a copy of the manifest of the project to edit, nil
+// when the project has none.
+func (g *Generator) manifestCopy() *Manifest {
+	if g.Manifest == nil {
+		return nil
+	}
+
+	manifest := *g.Manifest
+	manifest.Files = append([]ManifestFile(nil), g.Manifest.Files...)
+	manifest.Version = Version()
+	return &manifest
+}
+
+// manifestPath returns filePath as recorded in the manifest, relative to
+// the project root.
+func (g *Generator) manifestPath(filePath string) string {
+	rel, err := filepath.Rel(g.Root, filePath)
+	if err != nil {
+		return filepath.ToSlash(filePath)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Refactor writes the changes of r and the updated manifest. If writing
+// fails, every change is rolled back.
+func (g *Generator) Refactor(r *Refactoring) error {
+	tx := newTransaction(g.Afs)
+	if err := g.refactor(tx, r); err != nil {
+		return tx.rollback(err)
+	}
+
+	if r.manifest != nil {
+		g.Manifest = r.manifest
+	}
+	return nil
+}
+
+func (g *Generator) refactor(tx *transaction, r *Refactoring) error {
+	for _, change := range r.Changes {
+		var err error
+		if change.Action == ChangeRemove {
+			err = tx.removeFile(change.FilePath)
+		} else {
+			err = tx.writeFile(change.FilePath, change.Data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.manifest == nil {
+		return nil
+	}
+
+	data, err := r.manifest.encode()
+	if err != nil {
+		return err
+	}
+	return tx.writeFile(filepath.Join(g.Root, ManifestName), data)
+}
@@ -0,0 +1,301 @@
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/spf13/afero"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Registration decides where a generated command is added to its parent.
+type Registration string
+
+const (
+	// RegisterInit registers the command from an init() in its own file.
+	RegisterInit Registration = "init"
+	// RegisterParent inserts the registration into the init() of the parent,
+	// so that the command tree reads from the parent's source.
+	RegisterParent Registration = "parent"
+)
+
+// Registrations lists every supported Registration.
+var Registrations = []Registration{RegisterInit, RegisterParent}
+
+// ParseRegistration returns the Registration named by value.
+func ParseRegistration(value string) (Registration, error) {
+	names := make([]string, 0, len(Registrations))
+	for _, registration := range Registrations {
+		if string(registration) == value {
+			return registration, nil
+		}
+		names = append(names, string(registration))
+	}
+	return "", fmt.Errorf("unknown registration %q (use one of: %s)", value, strings.Join(names, ", "))
+}
+
+// initFile returns the file whose init() registers the children of parent:
+// the file declaring parent when its init() already calls
+// parent.AddCommand, or else a file calling it that declares no command, or
+// else the file declaring parent. The file of another command is never
+// returned, since its children register themselves from there by default.
+// It returns "" when parent is not declared.
+func (pkg *commandPackage) initFile(parent string) string {
+	declaring := pkg.vars[parent]
+	commands := pkg.commands()
+
+	var shared string
+	for _, filePath := range pkg.paths() {
+		if !pkg.registersChildren(filePath, parent) {
+			continue
+		}
+		if filePath == declaring {
+			return filePath
+		}
+		if shared == "" && !slices.ContainsFunc(commands, func(command string) bool { return pkg.vars[command] == filePath }) {
+			shared = filePath
+		}
+	}
+	if shared != "" {
+		return shared
+	}
+	return declaring
+}
+
+// registersChildren reports whether the init() of filePath calls
+// parent.AddCommand.
+func (pkg *commandPackage) registersChildren(filePath, parent string) bool {
+	for _, decl := range pkg.files[filePath].Decls {
+		if fn := initFunc(decl); fn != nil && slices.ContainsFunc(fn.Body.List, func(stmt ast.Stmt) bool {
+			return addCommandCall(stmt, parent) != nil
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// initFunc returns decl when it is an init function with a body.
+func initFunc(decl ast.Decl) *ast.FuncDecl {
+	fn, ok := decl.(*ast.FuncDecl)
+	if !ok || fn.Recv != nil || fn.Name.Name != "init" || fn.Body == nil {
+		return nil
+	}
+	return fn
+}
+
+// methodCall returns the arguments of stmt when it is a call to
+// recv.method.
+func methodCall(stmt ast.Stmt, recv, method string) []ast.Expr {
+	expr, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return nil
+	}
+	call, ok := expr.X.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != method {
+		return nil
+	}
+	if x, ok := sel.X.(*ast.Ident); !ok || x.Name != recv {
+		return nil
+	}
+	return call.Args
+}
+
+// addCommandCall returns the arguments of stmt when it is a call to
+// parent.AddCommand.
+func addCommandCall(stmt ast.Stmt, parent string) []ast.Expr {
+	return methodCall(stmt, parent, "AddCommand")
+}
+
+// addCommandToInit inserts parent.AddCommand(child) into the init() of src,
+// after the other children of parent when it has some, and appends an
+// init() when src has none. src is returned as is when child is already
+// registered.
+func addCommandToInit(src []byte, filename, parent, child string) ([]byte, error) {
+	registered, err := initHas(src, filename, func(stmt ast.Stmt) bool {
+		return slices.ContainsFunc(addCommandCall(stmt, parent), func(arg ast.Expr) bool {
+			ident, ok := arg.(*ast.Ident)
+			return ok && ident.Name == child
+		})
+	})
+	if err != nil || registered {
+		return src, err
+	}
+
+	return insertIntoInit(src, filename, fmt.Sprintf("%s.AddCommand(%s)", parent, child), func(stmt ast.Stmt) bool {
+		return addCommandCall(stmt, parent) != nil
+	})
+}
+
+// initHas reports whether a statement of an init() of src matches.
+func initHas(src []byte, filename string, match func(ast.Stmt) bool) (bool, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return false, err
+	}
+
+	for _, decl := range file.Decls {
+		if fn := initFunc(decl); fn != nil && slices.ContainsFunc(fn.Body.List, match) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// insertIntoInit inserts stmt into an init() of src: after the last
+// statement matching after, or else at the end of the first init(), and
+// appends an init() when src has none. The source is edited in place and
+// gofmt'd, so comments and formatting are kept.
+func insertIntoInit(src []byte, filename, stmt string, after func(ast.Stmt) bool) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		fn   *ast.FuncDecl
+		last ast.Stmt
+	)
+	for _, decl := range file.Decls {
+		candidate := initFunc(decl)
+		if candidate == nil {
+			continue
+		}
+		if fn == nil {
+			fn = candidate
+		}
+
+		for _, s := range candidate.Body.List {
+			if after(s) {
+				fn, last = candidate, s
+			}
+		}
+	}
+
+	var out []byte
+	switch {
+	case fn == nil:
+		out = append(bytes.TrimRight(src, "\n"), "\n\nfunc init() {\n\t"+stmt+"\n}\n"...)
+	case last != nil:
+		// after the end of the line, past any trailing comment
+		out = insertAt(src, lineEnd(src, fset.Position(last.End()).Offset), "\n\t"+stmt)
+	default:
+		offset := fset.Position(fn.Body.Rbrace).Offset
+		insert := "\t" + stmt + "\n"
+		if line := src[lineStart(src, offset):offset]; len(bytes.TrimSpace(line)) > 0 {
+			insert = "\n" + insert
+		}
+		out = insertAt(src, offset, insert)
+	}
+
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, fmt.Errorf("insert %s in %s: %w", stmt, filename, err)
+	}
+	return formatted, nil
+}
+
+// dropEmptyInits removes the init() functions of src left without
+// statements or comments.
+func dropEmptyInits(src []byte, filename string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var edits []textEdit
+	for _, decl := range file.Decls {
+		fn := initFunc(decl)
+		if fn == nil || len(fn.Body.List) > 0 || fn.Doc != nil || slices.ContainsFunc(file.Comments, func(group *ast.CommentGroup) bool {
+			return group.Pos() > fn.Body.Lbrace && group.End() < fn.Body.Rbrace
+		}) {
+			continue
+		}
+		edits = append(edits, deleteLines(fset, src, fn))
+	}
+	if len(edits) == 0 {
+		return src, nil
+	}
+	return format.Source(applyEdits(src, edits))
+}
+
+// stageRegistrations registers every command generated with RegisterParent
+// into the init() of its parent, editing the copy of the parent's file in
+// mem, taken from the filesystem unless the parent is generated too.
+// pathOf returns where a Content is found in mem, "" when it is not
+// generated. The files changed are returned.
+func (g *Generator) stageRegistrations(mem afero.Fs, pathOf func(Content) string) ([]string, error) {
+	var (
+		pkg     *commandPackage
+		changed []string
+	)
+
+	for _, content := range g.Content {
+		command, ok := content.Data.(Command)
+		if content.Dirty || !ok || command.Registration != RegisterParent || pathOf(content) == "" {
+			continue
+		}
+
+		filePath := g.generatedCommand(command.CmdParent, pathOf)
+		if filePath == "" {
+			if pkg == nil {
+				var err error
+				if pkg, err = parseCommandPackage(g.Afs, filepath.Dir(content.FilePath)); err != nil {
+					return nil, err
+				}
+			}
+			filePath = pkg.initFile(command.CmdParent)
+		}
+		if filePath == "" {
+			return nil, fmt.Errorf("parent command %s is not declared in package cmd", command.CmdParent)
+		}
+
+		src, err := afero.ReadFile(mem, filePath)
+		if err != nil {
+			if src, err = afero.ReadFile(g.Afs, filePath); err != nil {
+				return nil, err
+			}
+		}
+
+		edited, err := addCommandToInit(src, filePath, command.CmdParent, command.CmdName+"Cmd")
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(edited, src) {
+			continue
+		}
+
+		if err := mem.MkdirAll(filepath.Dir(filePath), 0751); err != nil {
+			return nil, err
+		}
+		if err := writeFileContent(mem, filePath, edited); err != nil {
+			return nil, err
+		}
+		if !slices.Contains(changed, filePath) {
+			changed = append(changed, filePath)
+		}
+	}
+	return changed, nil
+}
+
+// generatedCommand returns where the command declaring variable name is
+// generated, or "" when it is not generated.
+func (g *Generator) generatedCommand(name string, pathOf func(Content) string) string {
+	for _, content := range g.Content {
+		if command, ok := content.Data.(Command); ok && !content.Dirty && command.CmdName+"Cmd" == name {
+			return pathOf(content)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package project
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddCommandToInit(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "after the other children",
+			src:  "package cmd\n\nfunc init() {\n\tcobra.OnInitialize(initConfig)\n\n\t// commands\n\trootCmd.AddCommand(serveCmd) // serve the API\n\n\trootCmd.Flags().Bool(\"x\", false, \"\")\n}\n",
+			want: "package cmd\n\nfunc init() {\n\tcobra.OnInitialize(initConfig)\n\n\t// commands\n\trootCmd.AddCommand(serveCmd) // serve the API\n\trootCmd.AddCommand(userCmd)\n\n\trootCmd.Flags().Bool(\"x\", false, \"\")\n}\n",
+		},
+		{
+			name: "at the end of init",
+			src:  "package cmd\n\nfunc init() {\n\t// flags\n\trootCmd.Flags().Bool(\"x\", false, \"\")\n}\n",
+			want: "package cmd\n\nfunc init() {\n\t// flags\n\trootCmd.Flags().Bool(\"x\", false, \"\")\n\trootCmd.AddCommand(userCmd)\n}\n",
+		},
+		{
+			name: "empty init",
+			src:  "package cmd\n\nfunc init() {}\n",
+			want: "package cmd\n\nfunc init() {\n\trootCmd.AddCommand(userCmd)\n}\n",
+		},
+		{
+			name: "no init",
+			src:  "package cmd\n\n// rootCmd is the root\nvar rootCmd = &cobra.Command{}\n",
+			want: "package cmd\n\n// rootCmd is the root\nvar rootCmd = &cobra.Command{}\n\nfunc init() {\n\trootCmd.AddCommand(userCmd)\n}\n",
+		},
+		{
+			name: "already registered",
+			src:  "package cmd\n\nfunc init() {\n\trootCmd.AddCommand(serveCmd, userCmd)\n}\n",
+			want: "package cmd\n\nfunc init() {\n\trootCmd.AddCommand(serveCmd, userCmd)\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addCommandToInit([]byte(tt.src), "root.go", "rootCmd", "userCmd")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := compareContent(got, []byte(tt.want)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRegisterParent(t *testing.T) {
+	viper.Set("license", "none")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+
+	project, err := NewProject([]string{"myproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project.SetPkgName("github.com/acme/myproject")
+
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.PrepareModels(); err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.CreateProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	cmdDir := filepath.Join(project.AbsolutePath, "cmd")
+
+	command, err := NewProject([]string{"config/create"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	command.SetAbsolutePath(filepath.Join(cmdDir, "config", "create"))
+
+	generator, err = NewProjectGenerator(fs, command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator.Registration = RegisterParent
+	if err := generator.AddCommandProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertRegistered(t, fs, filepath.Join(cmdDir, "root.go"), "rootCmd.AddCommand(configCmd)")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "config.go"), "configCmd.AddCommand(createCmd)")
+
+	create, err := afero.ReadFile(fs, filepath.Join(cmdDir, "create.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(create), "func init()") {
+		t.Errorf("create.go registers itself:\n%s", create)
+	}
+
+	// the file of a sibling registering itself is never picked, even when
+	// it sorts before the file declaring the parent
+	addCommand(t, fs, cmdDir, "alpha", "rootCmd")
+	addCommandRegisteredBy(t, fs, cmdDir, "beta", "rootCmd")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "root.go"), "rootCmd.AddCommand(configCmd)\n\trootCmd.AddCommand(betaCmd)")
+	if alpha, err := afero.ReadFile(fs, filepath.Join(cmdDir, "alpha.go")); err != nil || strings.Contains(string(alpha), "betaCmd") {
+		t.Errorf("betaCmd is registered in alpha.go:\n%s", alpha)
+	}
+
+	// the children of serveCmd are registered in a file declaring no command
+	// that already registers them, not in the file declaring serveCmd
+	if err := afero.WriteFile(fs, filepath.Join(cmdDir, "serve.go"), []byte("package cmd\n\nvar serveCmd = &cobra.Command{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(fs, filepath.Join(cmdDir, "commands.go"), []byte("package cmd\n\nfunc init() {\n\trootCmd.AddCommand(serveCmd)\n\tserveCmd.AddCommand(statusCmd)\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	addCommand(t, fs, cmdDir, "stop", "serveCmd")
+	generator = addCommandRegisteredBy(t, fs, cmdDir, "restart", "serveCmd")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "commands.go"), "serveCmd.AddCommand(statusCmd)\n\tserveCmd.AddCommand(restartCmd)")
+
+	manifest, err := LoadManifest(fs, generator.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file, ok := manifest.File("cmd/restart.go"); !ok || file.Registration != string(RegisterParent) {
+		t.Errorf("cmd/restart.go is not recorded as registered by its parent: %+v", file)
+	}
+}
+
+func addCommandRegisteredBy(t *testing.T, fs afero.Fs, cmdDir, name, parent string) *Generator {
+	t.Helper()
+
+	command, err := NewProject([]string{name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	command.SetAbsolutePath(filepath.Join(cmdDir, name))
+
+	generator, err := NewProjectGenerator(fs, command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator.Parent = parent
+	generator.Registration = RegisterParent
+
+	if err := generator.AddCommandProject(); err != nil {
+		t.Fatalf("add %s: %v", name, err)
+	}
+	return generator
+}
@@ -0,0 +1,186 @@
+package project
+
+import (
+	"fmt"
+	"github.com/spf13/afero"
+	"go/ast"
+	"go/format"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// PlanRemove computes the removal of the command name, given as the name of
+// the command or as its variable, from the cmd package of the project
+// holding dir: the file declaring it is removed along with its
+// registration to its parent. A command with children is only removed when
+// recursive is set, together with all of its descendants. Removing fails
+// when a file that stays still uses what is removed.
+func (g *Generator) PlanRemove(dir, name string, recursive bool) (*Refactoring, error) {
+	rootGo, err := g.locateRootGo(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := parseCommandPackage(g.Afs, filepath.Dir(rootGo))
+	if err != nil {
+		return nil, err
+	}
+
+	cmdVar, err := pkg.commandVar(name)
+	if err != nil {
+		return nil, err
+	}
+	if cmdVar == "rootCmd" {
+		return nil, fmt.Errorf("the root command cannot be removed")
+	}
+
+	removed := []string{cmdVar}
+	for i := 0; i < len(removed); i++ {
+		children := pkg.children(removed[i])
+		if len(children) > 0 && !recursive {
+			return nil, fmt.Errorf("command %s has children (%s), remove them first or pass --recursive", removed[i], strings.Join(children, ", "))
+		}
+		for _, child := range children {
+			if !slices.Contains(removed, child) {
+				removed = append(removed, child)
+			}
+		}
+	}
+
+	var files []string
+	for _, command := range removed {
+		filePath := pkg.vars[command]
+		if slices.Contains(files, filePath) {
+			continue
+		}
+		for _, other := range pkg.commands() {
+			if pkg.vars[other] == filePath && !slices.Contains(removed, other) {
+				return nil, fmt.Errorf("%s declares %s along with %s, remove it by hand", filePath, command, other)
+			}
+		}
+		files = append(files, filePath)
+	}
+
+	// every variable of the removed files goes away, flags included
+	var gone []string
+	for variable, filePath := range pkg.vars {
+		if slices.Contains(files, filePath) {
+			gone = append(gone, variable)
+		}
+	}
+
+	r := &Refactoring{}
+	for _, filePath := range files {
+		r.remove(filePath)
+	}
+
+	edits := map[string][]textEdit{}
+	dropped := map[ast.Stmt]bool{}
+	for _, registration := range pkg.registrations() {
+		if slices.Contains(files, registration.file) {
+			continue
+		}
+
+		kept := slices.DeleteFunc(slices.Clone(registration.children), func(child string) bool {
+			return slices.Contains(removed, child)
+		})
+		if len(kept) == len(registration.children) {
+			continue
+		}
+
+		src, err := afero.ReadFile(g.Afs, registration.file)
+		if err != nil {
+			return nil, err
+		}
+
+		dropped[registration.stmt] = true
+		edits[registration.file] = append(edits[registration.file], registrationEdit(pkg, src, registration, kept))
+	}
+
+	for _, filePath := range pkg.paths() {
+		if slices.Contains(files, filePath) {
+			continue
+		}
+		if err := pkg.checkUnused(pkg.files[filePath], gone, dropped); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range pkg.tests {
+		if err := pkg.checkUnused(file, gone, dropped); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, filePath := range pkg.paths() {
+		if len(edits[filePath]) == 0 {
+			continue
+		}
+
+		src, err := afero.ReadFile(g.Afs, filePath)
+		if err != nil {
+			return nil, err
+		}
+		formatted, err := format.Source(applyEdits(src, edits[filePath]))
+		if err != nil {
+			return nil, fmt.Errorf("remove %s from %s: %w", cmdVar, filePath, err)
+		}
+		if formatted, err = dropEmptyInits(formatted, filePath); err != nil {
+			return nil, err
+		}
+		r.update(filePath, formatted)
+	}
+
+	if r.manifest = g.manifestCopy(); r.manifest != nil {
+		r.manifest.Files = slices.DeleteFunc(r.manifest.Files, func(file ManifestFile) bool {
+			return slices.ContainsFunc(files, func(filePath string) bool { return g.manifestPath(filePath) == file.Path })
+		})
+	}
+	return r, nil
+}
+
+// registrationEdit returns the edit leaving registration with the kept
+// children only, removing the whole statement when none is kept.
+func registrationEdit(pkg *commandPackage, src []byte, registration commandRegistration, kept []string) textEdit {
+	if len(kept) == 0 {
+		return deleteLines(pkg.fset, src, registration.stmt)
+	}
+
+	call := ast.Unparen(registration.stmt.(*ast.ExprStmt).X).(*ast.CallExpr)
+	return textEdit{
+		start: pkg.fset.Position(call.Args[0].Pos()).Offset,
+		end:   pkg.fset.Position(call.Args[len(call.Args)-1].End()).Offset,
+		text:  strings.Join(kept, ", "),
+	}
+}
+
+// checkUnused fails when file uses one of the package variables gone
+// outside of the statements dropped.
+func (pkg *commandPackage) checkUnused(file *ast.File, gone []string, dropped map[ast.Stmt]bool) error {
+	var used *ast.Ident
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case ast.Stmt:
+			return used == nil && !dropped[node]
+		case *ast.SelectorExpr:
+			// only the operand can be a variable of the package
+			ast.Inspect(node.X, func(node ast.Node) bool {
+				if ident, ok := node.(*ast.Ident); ok && used == nil && slices.Contains(gone, ident.Name) {
+					used = ident
+				}
+				return used == nil
+			})
+			return false
+		case *ast.Ident:
+			if used == nil && slices.Contains(gone, node.Name) {
+				used = node
+			}
+		}
+		return used == nil
+	})
+
+	if used != nil {
+		return fmt.Errorf("%s is still used at %s, remove the use first", used.Name, pkg.fset.Position(used.Pos()))
+	}
+	return nil
+}
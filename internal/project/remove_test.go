@@ -0,0 +1,101 @@
+package project
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRemove(t *testing.T) {
+	viper.Set("license", "none")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	cmdDir := createTestProject(t, fs)
+	addCommand(t, fs, cmdDir, "config/set", "rootCmd")
+	addCommand(t, fs, cmdDir, "serve", "rootCmd")
+
+	commandsGo := filepath.Join(cmdDir, "commands.go")
+	if err := afero.WriteFile(fs, commandsGo, []byte(`package cmd
+
+import "github.com/spf13/cobra"
+
+var versionCmd = &cobra.Command{Use: "version"}
+
+func init() {
+	// serve is registered twice on purpose
+	rootCmd.AddCommand(serveCmd, versionCmd)
+}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := planRemove(t, fs, cmdDir, "config", false); err == nil || !strings.Contains(err.Error(), "has children (setCmd)") {
+		t.Fatalf("got error %v, want config to have children", err)
+	}
+	if _, err := planRemove(t, fs, cmdDir, "rootCmd", false); err == nil {
+		t.Fatal("the root command was removed")
+	}
+
+	usesGo := filepath.Join(cmdDir, "uses.go")
+	if err := afero.WriteFile(fs, usesGo, []byte("package cmd\n\nfunc serveName() string {\n\treturn serveCmd.Name()\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := planRemove(t, fs, cmdDir, "serve", false); err == nil || !strings.Contains(err.Error(), "serveCmd is still used at "+usesGo) {
+		t.Fatalf("got error %v, want serveCmd to be used", err)
+	}
+	if err := fs.Remove(usesGo); err != nil {
+		t.Fatal(err)
+	}
+
+	generator, err := planRemove(t, fs, cmdDir, "serve", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := planRemove(t, fs, cmdDir, "config", true); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"serve.go", "config.go", "set.go"} {
+		if stat(fs, filepath.Join(cmdDir, name)) {
+			t.Errorf("%s was not removed", name)
+		}
+	}
+	assertRegistered(t, fs, commandsGo, "\trootCmd.AddCommand(versionCmd)\n")
+
+	manifest, err := LoadManifest(fs, generator.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range manifest.Files {
+		if file.Command != "" {
+			t.Errorf("%s is still recorded", file.Path)
+		}
+	}
+}
+
+// planRemove removes the command name from the project of cmdDir, unless
+// planning the removal fails.
+func planRemove(t *testing.T, fs afero.Fs, cmdDir, name string, recursive bool) (*Generator, error) {
+	t.Helper()
+
+	project, err := NewProject(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refactoring, err := generator.PlanRemove(cmdDir, name, recursive)
+	if err != nil {
+		return nil, err
+	}
+	if err := generator.Refactor(refactoring); err != nil {
+		t.Fatal(err)
+	}
+	return generator, nil
+}
@@ -0,0 +1,194 @@
+package project
+
+import (
+	"fmt"
+	"github.com/spf13/afero"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PlanRename computes the renaming of the command oldName, given as the
+// name of the command or as its variable, to newName in the cmd package of
+// the project holding dir: its variable and every reference to it, found by
+// type-checking the package with its tests, the first word of its Use and,
+// when it is named after the command, the file declaring it.
+func (g *Generator) PlanRename(dir, oldName, newName string) (*Refactoring, error) {
+	rootGo, err := g.locateRootGo(dir)
+	if err != nil {
+		return nil, err
+	}
+	cmdDir := filepath.Dir(rootGo)
+
+	pkg, err := parseCommandPackage(g.Afs, cmdDir)
+	if err != nil {
+		return nil, err
+	}
+
+	oldVar, err := pkg.commandVar(oldName)
+	if err != nil {
+		return nil, err
+	}
+	if oldVar == "rootCmd" {
+		return nil, fmt.Errorf("the root command cannot be renamed")
+	}
+
+	if !commandName.MatchString(newName) {
+		return nil, fmt.Errorf("invalid command name %q", newName)
+	}
+	cmdName := validateCmdName([]string{newName})
+	newVar := cmdName + "Cmd"
+	if filePath, ok := pkg.vars[newVar]; ok {
+		return nil, fmt.Errorf("%s is already declared in %s", newVar, filePath)
+	}
+
+	refs, err := pkg.references(oldVar)
+	if err != nil {
+		return nil, err
+	}
+
+	edits := map[string][]textEdit{}
+	for filePath, offsets := range refs {
+		for _, offset := range offsets {
+			edits[filePath] = append(edits[filePath], textEdit{start: offset, end: offset + len(oldVar), text: newVar})
+		}
+	}
+
+	declaring := pkg.vars[oldVar]
+	src, err := afero.ReadFile(g.Afs, declaring)
+	if err != nil {
+		return nil, err
+	}
+	if _, lit := commandLiteral(pkg.files[declaring], oldVar); lit != nil {
+		if use := literalField(lit, "Use"); use != nil {
+			if value, ok := stringLit(use.Value); ok {
+				_, args, _ := strings.Cut(value, " ")
+				edits[declaring] = append(edits[declaring], textEdit{
+					start: pkg.fset.Position(use.Value.Pos()).Offset,
+					end:   pkg.fset.Position(use.Value.End()).Offset,
+					text:  strconv.Quote(strings.TrimSpace(cmdName + " " + args)),
+				})
+			}
+		}
+	}
+
+	target := declaring
+	if base := strings.TrimSuffix(filepath.Base(declaring), ".go"); base == oldName || base == strings.TrimSuffix(oldVar, "Cmd") {
+		target = filepath.Join(cmdDir, newName+".go")
+		if stat(g.Afs, target) {
+			return nil, fmt.Errorf("%s already exists", target)
+		}
+	}
+
+	r := &Refactoring{}
+	if target != declaring {
+		r.remove(declaring)
+	}
+	for _, filePath := range pkg.paths() {
+		if len(edits[filePath]) == 0 {
+			continue
+		}
+
+		if filePath != declaring {
+			if src, err = afero.ReadFile(g.Afs, filePath); err != nil {
+				return nil, err
+			}
+		}
+		formatted, err := format.Source(applyEdits(src, edits[filePath]))
+		if err != nil {
+			return nil, fmt.Errorf("rename %s in %s: %w", oldVar, filePath, err)
+		}
+
+		switch {
+		case filePath == declaring && target != declaring:
+			r.create(target, formatted)
+		default:
+			r.update(filePath, formatted)
+		}
+	}
+
+	// test files are not part of pkg
+	for filePath, offsets := range refs {
+		if _, ok := pkg.files[filePath]; ok || len(offsets) == 0 {
+			continue
+		}
+		src, err := afero.ReadFile(g.Afs, filePath)
+		if err != nil {
+			return nil, err
+		}
+		formatted, err := format.Source(applyEdits(src, edits[filePath]))
+		if err != nil {
+			return nil, fmt.Errorf("rename %s in %s: %w", oldVar, filePath, err)
+		}
+		r.update(filePath, formatted)
+	}
+
+	if r.manifest = g.manifestCopy(); r.manifest != nil {
+		for i, file := range r.manifest.Files {
+			if file.Path == g.manifestPath(declaring) {
+				r.manifest.Files[i].Path = g.manifestPath(target)
+				r.manifest.Files[i].Command = cmdName
+			}
+			if file.Parent == oldVar {
+				r.manifest.Files[i].Parent = newVar
+			}
+		}
+	}
+	return r, nil
+}
+
+// references returns, by file, the offsets of the identifiers referring to
+// the package-level variable name of the package, its tests included. The
+// package is type-checked from the files parsed from the filesystem of the
+// generator, so that the offsets are those of their content and the files
+// keyed as in paths. Type errors, such as its imports left unresolved, are
+// tolerated: the identifiers of the package itself resolve regardless.
+func (pkg *commandPackage) references(name string) (map[string][]int, error) {
+	paths := append(pkg.paths(), pkg.testPaths()...)
+	files := make([]*ast.File, 0, len(paths))
+	for _, filePath := range paths {
+		file, ok := pkg.files[filePath]
+		if !ok {
+			file = pkg.tests[filePath]
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s is not declared", name)
+	}
+
+	info := &types.Info{
+		Defs: map[*ast.Ident]types.Object{},
+		Uses: map[*ast.Ident]types.Object{},
+	}
+	conf := types.Config{Error: func(error) {}}
+	checked, _ := conf.Check(files[0].Name.Name, pkg.fset, files, info)
+
+	obj := checked.Scope().Lookup(name)
+	if obj == nil {
+		return nil, fmt.Errorf("%s was not found by type-checking the package", name)
+	}
+
+	refs := map[string][]int{}
+	add := func(ident *ast.Ident) {
+		position := pkg.fset.Position(ident.Pos())
+		if !slices.Contains(refs[position.Filename], position.Offset) {
+			refs[position.Filename] = append(refs[position.Filename], position.Offset)
+		}
+	}
+	for ident, def := range info.Defs {
+		if def == obj {
+			add(ident)
+		}
+	}
+	for ident, use := range info.Uses {
+		if use == obj {
+			add(ident)
+		}
+	}
+	return refs, nil
+}
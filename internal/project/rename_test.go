@@ -0,0 +1,102 @@
+package project
+
+import (
+	"github.com/spf13/afero"
+	"path/filepath"
+	"testing"
+)
+
+func TestRename(t *testing.T) {
+	// in memory, the directory of the project is empty on disk: references
+	// are found in, and applied to, the files of the generator filesystem
+	t.Run("os", func(t *testing.T) { testRename(t, afero.NewOsFs(), t.TempDir()) })
+	t.Run("mem", func(t *testing.T) { testRename(t, afero.NewMemMapFs(), t.TempDir()) })
+}
+
+func testRename(t *testing.T, fs afero.Fs, root string) {
+	cmdDir := filepath.Join(root, "cmd")
+	files := map[string]string{
+		"go.mod":  "module example.com/app\n\ngo 1.24\n",
+		"LICENSE": "Apache License\n",
+		"cmd/root.go": `package cmd
+
+import "github.com/spf13/cobra"
+
+var rootCmd = &cobra.Command{Use: "app"}
+`,
+		"cmd/serve.go": `package cmd
+
+import "github.com/spf13/cobra"
+
+var servePort int
+
+var serveCmd = &cobra.Command{
+	Use:   "serve [address]",
+	Short: "Start the server",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// serveCmd is mentioned in a comment, which is left alone
+		cmd.Println("serving")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().IntVar(&servePort, "port", 8080, "port to listen on")
+}
+`,
+		"cmd/commands.go": `package cmd
+
+func commandNames() []string {
+	serveCmd := "shadowed"
+	return []string{rootCmd.Name(), serveCmd}
+}
+`,
+		"cmd/serve_test.go": `package cmd
+
+import "testing"
+
+func TestServe(t *testing.T) {
+	if serveCmd.Use == "" {
+		t.Fatal("no use")
+	}
+}
+`,
+	}
+	for name, content := range files {
+		if err := fs.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := afero.WriteFile(fs, filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	project, err := NewProject(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := generator.PlanRename(root, "serve", "root"); err == nil {
+		t.Fatal("renamed to a declared command")
+	}
+
+	refactoring, err := generator.PlanRename(root, "serve", "run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.Refactor(refactoring); err != nil {
+		t.Fatal(err)
+	}
+
+	if stat(fs, filepath.Join(cmdDir, "serve.go")) {
+		t.Error("serve.go was not renamed")
+	}
+	assertFileMatchesGolden(t, fs, filepath.Join(cmdDir, "run.go"), "testdata/rename_command.golden")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "serve_test.go"), "if runCmd.Use")
+	assertRegistered(t, fs, filepath.Join(cmdDir, "commands.go"), `serveCmd := "shadowed"`)
+}
@@ -0,0 +1,55 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/afero"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// templateDir is the directory of the embedded templates.
+const templateDir = "tpl"
+
+// overlayFS serves the embedded templates, letting files with the same name
+// in a user directory take precedence. A user directory holding root.tmpl
+// overrides tpl/root.tmpl; anything it lacks falls back to the embedded FS.
+type overlayFS struct {
+	upper fs.FS
+	lower fs.FS
+}
+
+// newTemplateFS returns the templates to generate from: the embedded ones,
+// overridden by the files of dir when it is not empty.
+func newTemplateFS(afs afero.Fs, dir string) (fs.FS, error) {
+	if dir == "" {
+		return templates, nil
+	}
+
+	info, err := afs.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("templates directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("templates directory: %s is not a directory", dir)
+	}
+
+	return &overlayFS{
+		upper: afero.NewIOFS(afero.NewBasePathFs(afs, dir)),
+		lower: templates,
+	}, nil
+}
+
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	if rel, ok := strings.CutPrefix(name, templateDir+"/"); ok && path.Base(rel) == rel {
+		file, err := o.upper.Open(rel)
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return o.lower.Open(name)
+}
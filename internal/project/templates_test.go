@@ -0,0 +1,64 @@
+package project
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"testing"
+)
+
+func TestTemplatesOverride(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "/company/root_none.tmpl", []byte("package cmd // {{ .AppName }}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Set("templates", "/company")
+	viper.Set("license", "none")
+	defer viper.Reset()
+
+	project, err := NewProject([]string{"myproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := generator.PrepareModels(); err != nil {
+		t.Fatal(err)
+	}
+
+	mainNone, err := templates.ReadFile("tpl/main_none.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, content := range generator.Content {
+		switch content.Name {
+		case "root":
+			if content.TemplateContent != "package cmd // {{ .AppName }}\n" {
+				t.Errorf("root_none.tmpl was not overridden: %q", content.TemplateContent)
+			}
+		case "main":
+			if content.TemplateContent != string(mainNone) {
+				t.Errorf("main_none.tmpl did not fall back to the embedded template")
+			}
+		}
+	}
+}
+
+func TestTemplatesMissingDirectory(t *testing.T) {
+	viper.Set("templates", "/missing")
+	defer viper.Reset()
+
+	project, err := NewProject([]string{"myproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewProjectGenerator(afero.NewMemMapFs(), project); err == nil {
+		t.Fatal("expected an error for a missing templates directory")
+	}
+}
@@ -0,0 +1,300 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/afero"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type journalKind int
+
+const (
+	journalDir journalKind = iota
+	journalFile
+	journalReplace
+)
+
+// journalEntry records a single change made to a filesystem so that it can
+// be undone. Backup holds the previous content of replaced files.
+type journalEntry struct {
+	Kind   journalKind
+	Fs     afero.Fs
+	Path   string
+	Backup []byte
+	Mode   os.FileMode
+}
+
+// transaction journals the directories and files created while generating
+// a project so that a failure leaves the filesystem as it was found.
+type transaction struct {
+	afs     afero.Fs
+	journal []journalEntry
+}
+
+func newTransaction(afs afero.Fs) *transaction {
+	return &transaction{afs: afs}
+}
+
+// mkdirAll creates path and any missing parents, journaling every
+// directory that did not exist before.
+func (tx *transaction) mkdirAll(path string, perm os.FileMode) error {
+	var missing []string
+	for dir := filepath.Clean(path); !stat(tx.afs, dir); dir = filepath.Dir(dir) {
+		missing = append(missing, dir)
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	if err := tx.afs.MkdirAll(path, perm); err != nil {
+		return err
+	}
+
+	// journal parents first so that rollback removes children first
+	for i := len(missing) - 1; i >= 0; i-- {
+		tx.journal = append(tx.journal, journalEntry{Kind: journalDir, Fs: tx.afs, Path: missing[i]})
+	}
+	return nil
+}
+
+// writeFile writes data to path, keeping a backup of any previous content.
+func (tx *transaction) writeFile(path string, data []byte) error {
+	entry := journalEntry{Kind: journalFile, Fs: tx.afs, Path: path}
+
+	if info, err := tx.afs.Stat(path); err == nil {
+		backup, err := afero.ReadFile(tx.afs, path)
+		if err != nil {
+			return err
+		}
+		entry = journalEntry{Kind: journalReplace, Fs: tx.afs, Path: path, Backup: backup, Mode: info.Mode()}
+	}
+
+	if err := tx.mkdirAll(filepath.Dir(path), 0751); err != nil {
+		return err
+	}
+
+	// journal before writing, a partially written file must be undone too
+	tx.journal = append(tx.journal, entry)
+
+	file, err := tx.afs.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// removeFile removes path, keeping a backup of its content.
+func (tx *transaction) removeFile(path string) error {
+	info, err := tx.afs.Stat(path)
+	if err != nil {
+		return err
+	}
+	backup, err := afero.ReadFile(tx.afs, path)
+	if err != nil {
+		return err
+	}
+
+	tx.journal = append(tx.journal, journalEntry{Kind: journalReplace, Fs: tx.afs, Path: path, Backup: backup, Mode: info.Mode()})
+	return tx.afs.Remove(path)
+}
+
+// created journals a file of the filesystem of the transaction created
+// outside of it, such as the go.mod written by `go mod init`.
+func (tx *transaction) created(path string) {
+	tx.journal = append(tx.journal, journalEntry{Kind: journalFile, Fs: tx.afs, Path: path})
+}
+
+// rollback undoes every journaled change in reverse order and returns a
+// RollbackError wrapping cause.
+func (tx *transaction) rollback(cause error) error {
+	rbErr := &RollbackError{Err: cause}
+
+	for i := len(tx.journal) - 1; i >= 0; i-- {
+		entry := tx.journal[i]
+
+		var err error
+		switch entry.Kind {
+		case journalDir:
+			err = entry.Fs.Remove(entry.Path)
+			rbErr.RolledBack = append(rbErr.RolledBack, "removed directory "+entry.Path)
+		case journalFile:
+			err = entry.Fs.Remove(entry.Path)
+			if errors.Is(err, os.ErrNotExist) {
+				err = nil
+			}
+			rbErr.RolledBack = append(rbErr.RolledBack, "removed "+entry.Path)
+		case journalReplace:
+			err = afero.WriteFile(entry.Fs, entry.Path, entry.Backup, entry.Mode)
+			rbErr.RolledBack = append(rbErr.RolledBack, "restored "+entry.Path)
+		}
+
+		if err != nil {
+			rbErr.Failed = append(rbErr.Failed, err)
+		}
+	}
+
+	tx.journal = nil
+	return rbErr
+}
+
+// RollbackError is returned when generation failed after the filesystem
+// was modified. RolledBack describes every change that was undone, Failed
+// holds the errors of changes that could not be undone.
+type RollbackError struct {
+	Err        error
+	RolledBack []string
+	Failed     []error
+}
+
+func (e *RollbackError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(e.Err.Error())
+
+	if len(e.RolledBack) > 0 {
+		sb.WriteString("\nrolled back:")
+		for _, change := range e.RolledBack {
+			sb.WriteString("\n  " + change)
+		}
+	}
+
+	if len(e.Failed) > 0 {
+		sb.WriteString(fmt.Sprintf("\n%d change(s) could not be rolled back:", len(e.Failed)))
+		for _, err := range e.Failed {
+			sb.WriteString("\n  " + err.Error())
+		}
+	}
+	return sb.String()
+}
+
+func (e *RollbackError) Unwrap() error {
+	return e.Err
+}
+
+// staged is the rendered output of every Content, in generation order,
+// waiting to be committed to the real filesystem.
+type staged struct {
+	fs    afero.Fs
+	files []string
+}
+
+// stage renders every Content into an in-memory filesystem at the path the
+// conflict policy chose for it, followed by the updated manifest. Template
+// errors surface here, before the real filesystem is touched.
+func (g *Generator) stage() (*staged, error) {
+	s := &staged{fs: afero.NewMemMapFs()}
+	rendered := map[string][]byte{}
+
+	for _, content := range g.Content {
+		if content.Dirty {
+			continue
+		}
+
+		target := g.targetPath(content)
+		if target == "" {
+			continue
+		}
+
+		data, err := g.render(content)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.fs.MkdirAll(filepath.Dir(target), 0751); err != nil {
+			return nil, err
+		}
+
+		if err := writeFileContent(s.fs, target, data); err != nil {
+			return nil, err
+		}
+
+		s.files = append(s.files, target)
+		if target == content.FilePath {
+			rendered[target] = data
+		}
+	}
+
+	updated, err := g.stageUpdates(s.fs)
+	if err != nil {
+		return nil, err
+	}
+	s.files = append(s.files, updated...)
+
+	registered, err := g.stageRegistrations(s.fs, g.targetPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range registered {
+		if !slices.Contains(s.files, file) {
+			s.files = append(s.files, file)
+		}
+	}
+
+	if !g.recordsManifest() {
+		return s, nil
+	}
+
+	if err := g.stageSnapshots(s, rendered); err != nil {
+		return nil, err
+	}
+
+	manifest, err := g.manifestFile(rendered)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestPath := filepath.Join(g.Root, ManifestName)
+	if err := writeFileContent(s.fs, manifestPath, manifest); err != nil {
+		return nil, err
+	}
+	s.files = append(s.files, manifestPath)
+	return s, nil
+}
+
+// stageSnapshots stages a copy of the template of every recorded file that
+// the project does not hold yet, for `cobra-cli upgrade` to render again
+// once the templates changed.
+func (g *Generator) stageSnapshots(s *staged, rendered map[string][]byte) error {
+	for _, content := range g.Content {
+		if _, ok := rendered[content.FilePath]; content.Dirty || !ok {
+			continue
+		}
+
+		snapshot := filepath.Join(g.Root, snapshotPath(hashContent([]byte(content.TemplateContent))))
+		if stat(g.Afs, snapshot) || stat(s.fs, snapshot) {
+			continue
+		}
+
+		if err := s.fs.MkdirAll(filepath.Dir(snapshot), 0751); err != nil {
+			return err
+		}
+		if err := writeFileContent(s.fs, snapshot, []byte(content.TemplateContent)); err != nil {
+			return err
+		}
+		s.files = append(s.files, snapshot)
+	}
+	return nil
+}
+
+// commit copies every staged file to the filesystem of tx.
+func (s *staged) commit(tx *transaction) error {
+	for _, file := range s.files {
+		data, err := afero.ReadFile(s.fs, file)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.writeFile(file, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
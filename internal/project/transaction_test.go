@@ -0,0 +1,122 @@
+package project
+
+import (
+	"errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// failingFs fails to create the file named fail.
+type failingFs struct {
+	afero.Fs
+	fail string
+}
+
+func (f failingFs) Create(name string) (afero.File, error) {
+	if name == f.fail {
+		return nil, errors.New("disk full")
+	}
+	return f.Fs.Create(name)
+}
+
+func TestCreateProjectRollback(t *testing.T) {
+	viper.Set("license", "mit")
+	defer viper.Reset()
+
+	project, err := NewProject([]string{"myproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project.SetPkgName("github.com/acme/myproject")
+
+	mem := afero.NewMemMapFs()
+	readme := filepath.Join(project.AbsolutePath, "README.md")
+	if err := afero.WriteFile(mem, readme, []byte("hand written"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := failingFs{Fs: mem, fail: filepath.Join(project.AbsolutePath, "internal", "service", "service.go")}
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator.OnConflict = ConflictOverwrite
+
+	if err := generator.PrepareModels(); err != nil {
+		t.Fatal(err)
+	}
+
+	err = generator.CreateProject()
+	var rbErr *RollbackError
+	if !errors.As(err, &rbErr) {
+		t.Fatalf("expected a rollback error, got %v", err)
+	}
+
+	if !strings.Contains(rbErr.Error(), "disk full") || len(rbErr.RolledBack) == 0 || len(rbErr.Failed) != 0 {
+		t.Fatalf("unexpected rollback: %v", rbErr)
+	}
+
+	data, err := afero.ReadFile(mem, readme)
+	if err != nil || string(data) != "hand written" {
+		t.Fatalf("%s was not restored: %q, %v", readme, data, err)
+	}
+
+	for _, path := range []string{"cmd", "internal", "LICENSE", "main.go"} {
+		if exists, _ := afero.Exists(mem, filepath.Join(project.AbsolutePath, path)); exists {
+			t.Errorf("%s was not rolled back", path)
+		}
+	}
+}
+
+func TestCreateProjectStagingFailure(t *testing.T) {
+	viper.Set("license", "mit")
+	defer viper.Reset()
+
+	project, err := NewProject([]string{"myproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := afero.NewMemMapFs()
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := generator.PrepareModels(); err != nil {
+		t.Fatal(err)
+	}
+	generator.Content[len(generator.Content)-1].TemplateContent = "{{ .Broken"
+
+	if err := generator.CreateProject(); err == nil {
+		t.Fatal("expected a template error")
+	}
+
+	if _, err := fs.Stat(project.AbsolutePath); !os.IsNotExist(err) {
+		t.Fatalf("%s was created: %v", project.AbsolutePath, err)
+	}
+}
+
+func TestRollbackCreated(t *testing.T) {
+	mem := afero.NewMemMapFs()
+	goMod := filepath.Join("myproject", "go.mod")
+	if err := afero.WriteFile(mem, goMod, []byte("module myproject\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a file created outside the transaction is removed from its filesystem
+	tx := newTransaction(mem)
+	tx.created(goMod)
+	var rbErr *RollbackError
+	if err := tx.rollback(errors.New("go mod init failed")); !errors.As(err, &rbErr) || len(rbErr.Failed) != 0 {
+		t.Fatalf("unexpected rollback: %v", err)
+	}
+	if stat(mem, goMod) {
+		t.Errorf("%s was not rolled back", goMod)
+	}
+}
@@ -0,0 +1,622 @@
+package project
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/packages"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// CommandTree is the command tree of a project, found by statically
+// analyzing its cmd package and sub-packages. Orphans are the commands
+// declared but never registered to a parent, other than the root.
+type CommandTree struct {
+	Root    *CommandNode   `json:"root"`
+	Orphans []*CommandNode `json:"orphans,omitempty"`
+}
+
+// CommandNode is a cobra.Command literal of a project with what its
+// declaration and the calls made on it tell: its help, aliases, groups,
+// flags and the children registered with AddCommand. File is relative to
+// the project root.
+type CommandNode struct {
+	Var        string         `json:"var,omitempty"`
+	Package    string         `json:"package"`
+	File       string         `json:"file"`
+	Line       int            `json:"line"`
+	Name       string         `json:"name"`
+	Use        string         `json:"use"`
+	Short      string         `json:"short,omitempty"`
+	Long       string         `json:"long,omitempty"`
+	Aliases    []string       `json:"aliases,omitempty"`
+	GroupID    string         `json:"groupId,omitempty"`
+	Groups     []Group        `json:"groups,omitempty"`
+	Args       string         `json:"args,omitempty"`
+	Hidden     bool           `json:"hidden,omitempty"`
+	Deprecated string         `json:"deprecated,omitempty"`
+	Runnable   bool           `json:"runnable"`
+	Flags      []CommandFlag  `json:"flags,omitempty"`
+	Children   []*CommandNode `json:"children,omitempty"`
+
+	parent *CommandNode
+	// hooks are the Run functions set, such as PreRun and RunE
+	hooks []string
+}
+
+// CommandFlag is a flag declared on a command through its pflag.FlagSet.
+// Default is the Go expression of the default value, or its value when it
+// is a string constant.
+type CommandFlag struct {
+	Name       string `json:"name"`
+	Shorthand  string `json:"shorthand,omitempty"`
+	Type       string `json:"type"`
+	Default    string `json:"default,omitempty"`
+	Usage      string `json:"usage,omitempty"`
+	Persistent bool   `json:"persistent,omitempty"`
+	Required   bool   `json:"required,omitempty"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+}
+
+// Parent returns the command node is registered to, nil for the root and
+// orphans.
+func (node *CommandNode) Parent() *CommandNode {
+	return node.parent
+}
+
+// Path returns the names of the commands from the root to node.
+func (node *CommandNode) Path() string {
+	var names []string
+	for n := node; n != nil; n = n.parent {
+		names = append([]string{n.Name}, names...)
+	}
+	return strings.Join(names, " ")
+}
+
+// ChildGroup is a group of the children of a command, as its help lists
+// them.
+type ChildGroup struct {
+	Group
+	Children []*CommandNode
+}
+
+// ChildGroups returns the children of node by group, in the order node adds
+// its groups. The children in no group come last, in a group without ID;
+// those in a group node does not add, which cobra refuses, come in a group
+// of their own without title.
+func (node *CommandNode) ChildGroups() []ChildGroup {
+	groups := make([]ChildGroup, 0, len(node.Groups)+1)
+	for _, group := range node.Groups {
+		groups = append(groups, ChildGroup{Group: group})
+	}
+
+	var ungrouped []*CommandNode
+	for _, child := range node.Children {
+		if child.GroupID == "" {
+			ungrouped = append(ungrouped, child)
+			continue
+		}
+		i := slices.IndexFunc(groups, func(group ChildGroup) bool { return group.ID == child.GroupID })
+		if i < 0 {
+			i = len(groups)
+			groups = append(groups, ChildGroup{Group: Group{ID: child.GroupID}})
+		}
+		groups[i].Children = append(groups[i].Children, child)
+	}
+
+	groups = slices.DeleteFunc(groups, func(group ChildGroup) bool { return len(group.Children) == 0 })
+	if len(ungrouped) > 0 {
+		groups = append(groups, ChildGroup{Children: ungrouped})
+	}
+	return groups
+}
+
+// Commands returns every command of the tree, each before its children,
+// the orphans last.
+func (t *CommandTree) Commands() []*CommandNode {
+	var (
+		commands []*CommandNode
+		walk     func(node *CommandNode)
+	)
+	walk = func(node *CommandNode) {
+		commands = append(commands, node)
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+
+	if t.Root != nil {
+		walk(t.Root)
+	}
+	for _, orphan := range t.Orphans {
+		walk(orphan)
+	}
+	return commands
+}
+
+// pflagTypes are the types of the methods of pflag.FlagSet declaring flags:
+// String, StringVar, StringP and StringVarP declare a string flag.
+var pflagTypes = []string{
+	"Bool", "BoolSlice", "BytesBase64", "BytesHex", "Count", "Duration", "DurationSlice",
+	"Float32", "Float32Slice", "Float64", "Float64Slice", "IP", "IPMask", "IPNet", "IPSlice",
+	"Int", "Int16", "Int32", "Int32Slice", "Int64", "Int64Slice", "Int8", "IntSlice",
+	"String", "StringArray", "StringSlice", "StringToInt", "StringToInt64", "StringToString",
+	"Uint", "Uint16", "Uint32", "Uint64", "Uint8", "UintSlice",
+}
+
+// CommandTree statically analyzes the cmd package of the project holding
+// dir, and its sub-packages, with go/packages. The commands are the
+// cobra.Command literals bound to a variable, passed to AddCommand or
+// returned by a function; nothing of the project is executed. Packages are
+// read from the disk, whatever the filesystem of g.
+func (g *Generator) CommandTree(dir string) (*CommandTree, error) {
+	rootGo, err := g.locateRootGo(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs, err := loadPackages(filepath.Dir(rootGo), false, "./...")
+	if err != nil {
+		return nil, err
+	}
+
+	a := &treeAnalysis{
+		root:     g.Root,
+		vars:     map[types.Object]*CommandNode{},
+		literals: map[*ast.CompositeLit]*CommandNode{},
+		funcs:    map[types.Object]*CommandNode{},
+	}
+	for _, pkg := range pkgs {
+		a.declare(pkg)
+	}
+	for _, pkg := range pkgs {
+		a.connect(pkg)
+	}
+	return a.tree(), nil
+}
+
+// loadPackages loads the packages matching patterns from dir with their
+// syntax and types. Type errors, such as a dependency missing from the
+// module cache, are tolerated: the identifiers of the packages themselves
+// resolve regardless.
+func loadPackages(dir string, tests bool, patterns ...string) ([]*packages.Package, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir:   dir,
+		Tests: tests,
+	}, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("load packages of %s: %w", dir, err)
+	}
+
+	for _, pkg := range pkgs {
+		for _, pkgErr := range pkg.Errors {
+			if pkgErr.Kind == packages.ParseError {
+				return nil, pkgErr
+			}
+		}
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].ID < pkgs[j].ID })
+	return pkgs, nil
+}
+
+// treeAnalysis finds the commands of the packages, then the calls made on
+// them.
+type treeAnalysis struct {
+	root     string
+	commands []*CommandNode
+	vars     map[types.Object]*CommandNode
+	literals map[*ast.CompositeLit]*CommandNode
+	// funcs maps the functions returning a command literal to it
+	funcs map[types.Object]*CommandNode
+}
+
+// declare records every command literal of pkg.
+func (a *treeAnalysis) declare(pkg *packages.Package) {
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ValueSpec:
+				for i, value := range n.Values {
+					if lit := commandLit(pkg, value); lit != nil && i < len(n.Names) {
+						a.vars[pkg.TypesInfo.Defs[n.Names[i]]] = a.node(pkg, lit, n.Names[i].Name)
+					}
+				}
+			case *ast.AssignStmt:
+				for i, value := range n.Rhs {
+					if i >= len(n.Lhs) {
+						break
+					}
+					ident, ok := n.Lhs[i].(*ast.Ident)
+					if lit := commandLit(pkg, value); lit != nil && ok {
+						a.vars[objectOf(pkg, ident)] = a.node(pkg, lit, ident.Name)
+					}
+				}
+			case *ast.CompositeLit:
+				if _, ok := a.literals[n]; !ok && isCommandLit(pkg, n) {
+					a.node(pkg, n, "")
+				}
+			}
+			return true
+		})
+
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				ret, ok := n.(*ast.ReturnStmt)
+				if !ok || len(ret.Results) == 0 {
+					return !ok
+				}
+				if node := a.command(pkg, ret.Results[0]); node != nil {
+					a.funcs[pkg.TypesInfo.Defs[fn.Name]] = node
+				}
+				return true
+			})
+		}
+	}
+}
+
+// node records the command declared by lit.
+func (a *treeAnalysis) node(pkg *packages.Package, lit *ast.CompositeLit, variable string) *CommandNode {
+	if node, ok := a.literals[lit]; ok {
+		if node.Var == "" {
+			node.Var = variable
+		}
+		return node
+	}
+
+	position := pkg.Fset.Position(lit.Pos())
+	node := &CommandNode{
+		Var:     variable,
+		Package: pkg.PkgPath,
+		File:    a.relative(position.Filename),
+		Line:    position.Line,
+	}
+
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+
+		switch key.Name {
+		case "Use":
+			node.Use = constString(pkg, kv.Value)
+		case "Short":
+			node.Short = constString(pkg, kv.Value)
+		case "Long":
+			node.Long = constString(pkg, kv.Value)
+		case "GroupID":
+			node.GroupID = constString(pkg, kv.Value)
+		case "Deprecated":
+			node.Deprecated = constString(pkg, kv.Value)
+		case "Aliases":
+			if aliases, ok := kv.Value.(*ast.CompositeLit); ok {
+				for _, alias := range aliases.Elts {
+					node.Aliases = append(node.Aliases, constString(pkg, alias))
+				}
+			}
+		case "Args":
+			node.Args = types.ExprString(kv.Value)
+		case "Hidden":
+			node.Hidden = types.ExprString(kv.Value) == "true"
+		case "PersistentPreRun", "PersistentPreRunE", "PreRun", "PreRunE", "Run", "RunE",
+			"PostRun", "PostRunE", "PersistentPostRun", "PersistentPostRunE":
+			node.hooks = append(node.hooks, key.Name)
+			node.Runnable = node.Runnable || key.Name == "Run" || key.Name == "RunE"
+		}
+	}
+	node.Name, _, _ = strings.Cut(node.Use, " ")
+
+	a.literals[lit] = node
+	a.commands = append(a.commands, node)
+	return node
+}
+
+// connect follows the calls made on the commands of pkg: their flags,
+// groups and children.
+func (a *treeAnalysis) connect(pkg *packages.Package) {
+	var required []struct {
+		node       *CommandNode
+		name       string
+		persistent bool
+	}
+
+	a.alias(pkg)
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+
+			if node := a.command(pkg, sel.X); node != nil {
+				switch sel.Sel.Name {
+				case "AddCommand":
+					for _, arg := range call.Args {
+						if child := a.command(pkg, arg); child != nil && child.parent == nil && child != node {
+							child.parent = node
+							node.Children = append(node.Children, child)
+						}
+					}
+				case "AddGroup":
+					for _, arg := range call.Args {
+						node.Groups = append(node.Groups, commandGroup(pkg, arg))
+					}
+				case "MarkFlagRequired", "MarkPersistentFlagRequired":
+					if len(call.Args) == 1 {
+						required = append(required, struct {
+							node       *CommandNode
+							name       string
+							persistent bool
+						}{node, constString(pkg, call.Args[0]), sel.Sel.Name == "MarkPersistentFlagRequired"})
+					}
+				}
+				return true
+			}
+
+			// cmd.Flags().StringVarP(&v, "name", "n", "default", "usage")
+			set, ok := sel.X.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			setSel, ok := set.Fun.(*ast.SelectorExpr)
+			if !ok || (setSel.Sel.Name != "Flags" && setSel.Sel.Name != "PersistentFlags") {
+				return true
+			}
+			if node := a.command(pkg, setSel.X); node != nil {
+				if flag, ok := flagDeclaration(pkg, sel.Sel.Name, call.Args); ok {
+					flag.Persistent = setSel.Sel.Name == "PersistentFlags"
+					position := pkg.Fset.Position(call.Pos())
+					flag.File, flag.Line = a.relative(position.Filename), position.Line
+					node.Flags = append(node.Flags, flag)
+				}
+			}
+			return true
+		})
+	}
+
+	for _, mark := range required {
+		for i := range mark.node.Flags {
+			if mark.node.Flags[i].Name == mark.name && (!mark.persistent || mark.node.Flags[i].Persistent) {
+				mark.node.Flags[i].Required = true
+			}
+		}
+	}
+}
+
+// alias records the variables of pkg assigned a command another way than
+// by its literal, such as cmd := newVersionCmd().
+func (a *treeAnalysis) alias(pkg *packages.Package) {
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			var names []*ast.Ident
+			var values []ast.Expr
+			switch n := n.(type) {
+			case *ast.ValueSpec:
+				names, values = n.Names, n.Values
+			case *ast.AssignStmt:
+				for _, lhs := range n.Lhs {
+					ident, _ := lhs.(*ast.Ident)
+					names = append(names, ident)
+				}
+				values = n.Rhs
+			default:
+				return true
+			}
+
+			for i, value := range values {
+				if i >= len(names) || names[i] == nil || commandLit(pkg, value) != nil {
+					continue
+				}
+				if node := a.command(pkg, value); node != nil {
+					if obj := objectOf(pkg, names[i]); obj != nil && a.vars[obj] == nil {
+						a.vars[obj] = node
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+// tree returns the tree rooted at rootCmd, or else at the first command
+// never registered.
+func (a *treeAnalysis) tree() *CommandTree {
+	t := &CommandTree{}
+	for _, node := range a.commands {
+		if node.parent != nil {
+			continue
+		}
+		if t.Root == nil || (node.Var == "rootCmd" && t.Root.Var != "rootCmd") {
+			if t.Root != nil {
+				t.Orphans = append(t.Orphans, t.Root)
+			}
+			t.Root = node
+			continue
+		}
+		t.Orphans = append(t.Orphans, node)
+	}
+	return t
+}
+
+// command returns the command expr refers to: a variable holding one, a
+// literal or a call to a function returning one.
+func (a *treeAnalysis) command(pkg *packages.Package, expr ast.Expr) *CommandNode {
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		return a.vars[objectOf(pkg, expr)]
+	case *ast.SelectorExpr:
+		return a.vars[objectOf(pkg, expr.Sel)]
+	case *ast.UnaryExpr:
+		if lit, ok := expr.X.(*ast.CompositeLit); ok {
+			return a.literals[lit]
+		}
+	case *ast.CompositeLit:
+		return a.literals[expr]
+	case *ast.CallExpr:
+		switch fun := expr.Fun.(type) {
+		case *ast.Ident:
+			return a.funcs[objectOf(pkg, fun)]
+		case *ast.SelectorExpr:
+			return a.funcs[objectOf(pkg, fun.Sel)]
+		}
+	}
+	return nil
+}
+
+func (a *treeAnalysis) relative(filename string) string {
+	if a.root == "" {
+		return filename
+	}
+	if rel, err := filepath.Rel(a.root, filename); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filename
+}
+
+// objectOf returns the object ident defines or uses.
+func objectOf(pkg *packages.Package, ident *ast.Ident) types.Object {
+	if obj := pkg.TypesInfo.Uses[ident]; obj != nil {
+		return obj
+	}
+	return pkg.TypesInfo.Defs[ident]
+}
+
+// commandLit returns the cobra.Command literal expr is, or takes the
+// address of.
+func commandLit(pkg *packages.Package, expr ast.Expr) *ast.CompositeLit {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	if lit, ok := expr.(*ast.CompositeLit); ok && isCommandLit(pkg, lit) {
+		return lit
+	}
+	return nil
+}
+
+// isCommandLit reports whether lit is a cobra.Command, by its type or,
+// when cobra could not be loaded, by its syntax.
+func isCommandLit(pkg *packages.Package, lit *ast.CompositeLit) bool {
+	if named, ok := pkg.TypesInfo.TypeOf(lit).(*types.Named); ok {
+		obj := named.Obj()
+		return obj.Name() == "Command" && obj.Pkg() != nil && obj.Pkg().Path() == "github.com/spf13/cobra"
+	}
+	return lit.Type != nil && isCommandType(lit.Type)
+}
+
+// constString returns the value of expr when it is a string constant, and
+// its source otherwise.
+func constString(pkg *packages.Package, expr ast.Expr) string {
+	if tv, ok := pkg.TypesInfo.Types[expr]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+		return constant.StringVal(tv.Value)
+	}
+	if value, ok := stringLit(expr); ok {
+		return value
+	}
+	return types.ExprString(expr)
+}
+
+// commandGroup returns the group declared by a &cobra.Group{...} literal.
+func commandGroup(pkg *packages.Package, expr ast.Expr) Group {
+	if unary, ok := expr.(*ast.UnaryExpr); ok {
+		expr = unary.X
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return Group{ID: types.ExprString(expr)}
+	}
+
+	var group Group
+	if kv := literalField(lit, "ID"); kv != nil {
+		group.ID = constString(pkg, kv.Value)
+	}
+	if kv := literalField(lit, "Title"); kv != nil {
+		group.Title = constString(pkg, kv.Value)
+	}
+	return group
+}
+
+// flagDeclaration returns the flag declared by a call to the method of
+// pflag.FlagSet with args, and false when method declares no flag.
+func flagDeclaration(pkg *packages.Package, method string, args []ast.Expr) (CommandFlag, bool) {
+	var (
+		base      string
+		bound     bool
+		shorthand bool
+		found     bool
+	)
+	for _, suffix := range []string{"VarP", "Var", "P", ""} {
+		if base = strings.TrimSuffix(method, suffix); base != method || suffix == "" {
+			if slices.Contains(pflagTypes, base) || (base == "" && strings.HasPrefix(suffix, "Var")) {
+				bound, shorthand, found = strings.HasPrefix(suffix, "Var"), strings.HasSuffix(suffix, "P"), true
+				break
+			}
+		}
+	}
+	if !found {
+		return CommandFlag{}, false
+	}
+
+	// Var(value, name, usage) takes a pflag.Value, Count no default
+	hasDefault := base != "" && base != "Count"
+	i := 0
+	if bound {
+		i++
+	}
+	want := i + 1
+	if shorthand {
+		want++
+	}
+	if hasDefault {
+		want++
+	}
+	if len(args) < want+1 {
+		return CommandFlag{}, false
+	}
+
+	flag := CommandFlag{Name: constString(pkg, args[i]), Type: flagTypeName(base)}
+	if shorthand {
+		i++
+		flag.Shorthand = constString(pkg, args[i])
+	}
+	if hasDefault {
+		i++
+		flag.Default = constString(pkg, args[i])
+		if tv, ok := pkg.TypesInfo.Types[args[i]]; ok && tv.Value != nil && tv.Value.Kind() != constant.String {
+			flag.Default = tv.Value.ExactString()
+		}
+	}
+	flag.Usage = constString(pkg, args[i+1])
+	return flag, true
+}
+
+// flagTypeName returns the name of the type of a pflag method base:
+// StringSlice gives stringSlice and IPMask gives ipMask.
+func flagTypeName(base string) string {
+	switch {
+	case base == "":
+		return "value"
+	case strings.HasPrefix(base, "IP"):
+		return "ip" + base[2:]
+	default:
+		return strings.ToLower(base[:1]) + base[1:]
+	}
+}
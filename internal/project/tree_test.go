@@ -0,0 +1,161 @@
+package project
+
+import (
+	"fmt"
+	"github.com/spf13/afero"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCommandTree(t *testing.T) {
+	tree := commandTree(t, map[string]string{
+		"cmd/root.go": `package cmd
+
+import (
+	"example.com/app/cmd/admin"
+	"github.com/spf13/cobra"
+)
+
+const appName = "app"
+
+var rootCmd = &cobra.Command{
+	Use:   appName,
+	Short: "The application",
+}
+
+func init() {
+	rootCmd.AddGroup(&cobra.Group{ID: "manage", Title: "Management Commands"})
+	rootCmd.PersistentFlags().StringP("config", "c", "config.yaml", "config file")
+	rootCmd.AddCommand(serveCmd, newVersionCmd(), admin.UserCmd)
+}
+`,
+		"cmd/serve.go": `package cmd
+
+import "github.com/spf13/cobra"
+
+var servePort int
+
+var serveCmd = &cobra.Command{
+	Use:     "serve [address]",
+	Aliases: []string{"s", "run"},
+	Short:   "Start the server",
+	GroupID: "manage",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+}
+
+func init() {
+	serveCmd.Flags().IntVarP(&servePort, "port", "p", 8080, "port to listen on")
+	serveCmd.Flags().CountP("verbose", "v", "verbosity")
+	serveCmd.MarkFlagRequired("port")
+	serveCmd.AddCommand(&cobra.Command{Use: "status", Hidden: true})
+}
+
+func newVersionCmd() *cobra.Command {
+	return &cobra.Command{Use: "version", Deprecated: "use --version"}
+}
+
+var unusedCmd = &cobra.Command{Use: "unused"}
+`,
+		"cmd/admin/user.go": `package admin
+
+import "github.com/spf13/cobra"
+
+var UserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage users",
+}
+`,
+	})
+
+	var paths []string
+	for _, command := range tree.Commands() {
+		paths = append(paths, command.Path())
+	}
+	if want := []string{"app", "app serve", "app serve status", "app version", "app user", "unused"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("got commands %q, want %q", paths, want)
+	}
+
+	if want := []Group{{ID: "manage", Title: "Management Commands"}}; !reflect.DeepEqual(tree.Root.Groups, want) {
+		t.Errorf("got groups %v, want %v", tree.Root.Groups, want)
+	}
+	if want := []CommandFlag{{Name: "config", Shorthand: "c", Type: "string", Default: "config.yaml", Usage: "config file", Persistent: true, File: "cmd/root.go", Line: 17}}; !reflect.DeepEqual(tree.Root.Flags, want) {
+		t.Errorf("got root flags %+v, want %+v", tree.Root.Flags, want)
+	}
+
+	var groups []string
+	for _, group := range tree.Root.ChildGroups() {
+		groups = append(groups, fmt.Sprintf("%s:%d", group.ID, len(group.Children)))
+	}
+	if want := []string{"manage:1", ":2"}; !reflect.DeepEqual(groups, want) {
+		t.Errorf("got child groups %q, want %q", groups, want)
+	}
+
+	serve := tree.Root.Children[0]
+	if serve.File != "cmd/serve.go" || serve.Line != 7 || serve.Var != "serveCmd" {
+		t.Errorf("serve declared by %s at %s:%d", serve.Var, serve.File, serve.Line)
+	}
+	if !reflect.DeepEqual(serve.Aliases, []string{"s", "run"}) || serve.GroupID != "manage" || serve.Args != "cobra.MaximumNArgs(1)" || !serve.Runnable {
+		t.Errorf("got serve %+v", serve)
+	}
+	want := []CommandFlag{
+		{Name: "port", Shorthand: "p", Type: "int", Default: "8080", Usage: "port to listen on", Required: true, File: "cmd/serve.go", Line: 19},
+		{Name: "verbose", Shorthand: "v", Type: "count", Usage: "verbosity", File: "cmd/serve.go", Line: 20},
+	}
+	if !reflect.DeepEqual(serve.Flags, want) {
+		t.Errorf("got serve flags %+v, want %+v", serve.Flags, want)
+	}
+
+	if status := serve.Children[0]; !status.Hidden || status.Var != "" {
+		t.Errorf("got status %+v", status)
+	}
+	if version := tree.Root.Children[1]; version.Deprecated != "use --version" {
+		t.Errorf("got version %+v", version)
+	}
+	if user := tree.Root.Children[2]; user.Package != "example.com/app/cmd/admin" || user.Short != "Manage users" {
+		t.Errorf("got user %+v", user)
+	}
+}
+
+// commandTree returns the command tree of a project holding files, with
+// the cobra import left unresolved: commands are found by their syntax.
+func commandTree(t *testing.T, files map[string]string) *CommandTree {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("type-checking needs the go command")
+	}
+	t.Setenv("GOFLAGS", "-mod=mod")
+	t.Setenv("GOPROXY", "off")
+
+	fs := afero.NewOsFs()
+	root := t.TempDir()
+	files["go.mod"] = "module example.com/app\n\ngo 1.24\n"
+	files["LICENSE"] = "Apache License\n"
+	for name, content := range files {
+		if err := fs.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := afero.WriteFile(fs, filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	project, err := NewProject(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := generator.CommandTree(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
@@ -0,0 +1,244 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/spf13/afero"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	UpgradeUpToDate = "up-to-date"
+	UpgradeUpdated  = "updated"
+	UpgradeMerged   = "merged"
+	UpgradeConflict = "conflict"
+	UpgradeSkipped  = "skipped"
+)
+
+// UpgradeResult describes what upgrading did, or would do, with a single
+// generated file. Body is the upgraded content and Conflicts the number of
+// regions where the edits of the user clash with the new templates.
+type UpgradeResult struct {
+	FilePath  string
+	Status    string
+	Reason    string
+	Conflicts int
+	Body      []byte
+
+	file      ManifestFile
+	generated []byte
+	template  string
+}
+
+// PlanUpgrade computes the upgrade of every file recorded in the manifest of
+// the project holding dir, without writing anything. The original template
+// of a file, kept as a snapshot, and its current template are both rendered
+// with the inputs recorded in the manifest; the change between the two is
+// then merged into the file as the user left it.
+func (g *Generator) PlanUpgrade(dir string) ([]UpgradeResult, error) {
+	if err := g.loadProjectManifest(dir); err != nil {
+		return nil, err
+	}
+	if g.Manifest == nil {
+		return nil, fmt.Errorf("no %s found from %s, only projects generated with a manifest can be upgraded", ManifestName, dir)
+	}
+
+	g.Project = g.manifestProject()
+
+	comment, err := extractBlockCommentBeforePackage(g.Afs, filepath.Join(g.Root, "cmd", "root.go"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	results := make([]UpgradeResult, 0, len(g.Manifest.Files))
+	for _, file := range g.Manifest.Files {
+		var data any = g.Project
+		switch {
+		case file.Command != "":
+			data = Command{
+				CmdName:          file.Command,
+				CmdParent:        file.Parent,
+				ExtractedLicense: comment,
+				Registration:     Registration(file.Registration),
+				Spec:             file.Spec,
+				Project:          g.Project,
+			}
+		case strings.HasPrefix(path.Base(file.Template), "license_"):
+			data = g.Project.Legal
+		}
+
+		result, err := g.upgradeFile(file, data)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// manifestProject rebuilds the Project the manifest was generated from.
+func (g *Generator) manifestProject() *Project {
+	legal, found := &License{Code: g.Manifest.License}, false
+	for _, license := range contentLicenses(g.Templates) {
+		if license.Code == g.Manifest.License {
+			copied := *license
+			legal, found = &copied, true
+			break
+		}
+	}
+	if id, ok := spdxIdentifier(g.Manifest.License); ok && !found {
+		legal, found = spdxLicense(id), true
+	}
+	if found {
+		legal.Copyright = fmt.Sprintf("Copyright © %s %s", g.Manifest.Year, g.Manifest.Author)
+	}
+
+	return &Project{
+		PkgName:      g.Manifest.Module,
+		AbsolutePath: g.Root,
+		AppName:      g.Manifest.AppName,
+		Legal:        legal,
+		Vars:         g.Manifest.Vars,
+	}
+}
+
+func (g *Generator) upgradeFile(file ManifestFile, data any) (UpgradeResult, error) {
+	result := UpgradeResult{FilePath: filepath.Join(g.Root, filepath.FromSlash(file.Path)), file: file}
+
+	ours, err := afero.ReadFile(g.Afs, result.FilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		result.Status, result.Reason = UpgradeSkipped, "removed from the project"
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+
+	content := Content{
+		Name:             strings.TrimSuffix(path.Base(file.Template), path.Ext(file.Template)),
+		FilePath:         result.FilePath,
+		TemplateFilePath: file.Template,
+		Data:             data,
+	}
+
+	if content.TemplateContent, err = g.currentTemplate(file.Template); err != nil {
+		return result, err
+	}
+	theirs, err := g.render(content)
+	if err != nil {
+		return result, err
+	}
+	result.generated, result.template = theirs, content.TemplateContent
+
+	var base []byte
+	original, err := afero.ReadFile(g.Afs, filepath.Join(g.Root, snapshotPath(file.TemplateHash)))
+	switch {
+	case file.TemplateHash != "" && err == nil:
+		content.TemplateContent = string(original)
+		if base, err = g.render(content); err != nil {
+			return result, err
+		}
+	case !file.Modified(ours):
+		// untouched files are their own base
+		base = ours
+	default:
+		result.Status, result.Reason = UpgradeSkipped, "edited, and the original template was not recorded"
+		return result, nil
+	}
+
+	switch {
+	case bytes.Equal(base, theirs), bytes.Equal(ours, theirs):
+		result.Status, result.Body = UpgradeUpToDate, ours
+	case bytes.Equal(ours, base):
+		result.Status, result.Body = UpgradeUpdated, theirs
+	default:
+		result.Body, result.Conflicts = merge3(base, ours, theirs)
+		result.Status = UpgradeMerged
+		if result.Conflicts > 0 {
+			result.Status = UpgradeConflict
+		}
+	}
+	return result, nil
+}
+
+// currentTemplate returns the template a file recorded in the manifest is
+// generated from today: a built-in template, possibly overridden by the
+// templates directory, or a template of the loaded pack.
+func (g *Generator) currentTemplate(name string) (string, error) {
+	if strings.HasPrefix(name, templateDir+"/") {
+		data, err := fs.ReadFile(g.Templates, name)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+
+	if g.Pack != nil && g.Pack.Source != templateDir {
+		for _, file := range g.Pack.Files {
+			if name == path.Join(g.Pack.Source, file.Template) || strings.HasSuffix(name, "/"+file.Template) {
+				data, err := fs.ReadFile(g.Pack.FS, file.Template)
+				if err != nil {
+					return "", err
+				}
+				return string(data), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("template %s: not a built-in template, pass its template pack with --pack", name)
+}
+
+// Upgrade writes the results of PlanUpgrade and records the new templates
+// in the manifest. Its groupImports becomes GroupImports, which is
+// --group-imports OR'd with the groupImports the manifest recorded. Files
+// with conflicts are written with conflict markers. If writing fails, every
+// change is rolled back.
+func (g *Generator) Upgrade(results []UpgradeResult) error {
+	manifest := *g.Manifest
+	manifest.Files = append([]ManifestFile(nil), g.Manifest.Files...)
+	manifest.Version = Version()
+	manifest.GroupImports = g.GroupImports
+
+	tx := newTransaction(g.Afs)
+	if err := g.upgrade(tx, &manifest, results); err != nil {
+		return tx.rollback(err)
+	}
+
+	g.Manifest = &manifest
+	return nil
+}
+
+func (g *Generator) upgrade(tx *transaction, manifest *Manifest, results []UpgradeResult) error {
+	for _, result := range results {
+		if result.Status == UpgradeSkipped {
+			continue
+		}
+
+		if result.Status != UpgradeUpToDate {
+			if err := tx.writeFile(result.FilePath, result.Body); err != nil {
+				return err
+			}
+		}
+
+		file := result.file
+		file.Hash = hashContent(result.generated)
+		file.TemplateHash = hashContent([]byte(result.template))
+		manifest.setFile(file)
+
+		snapshot := filepath.Join(g.Root, snapshotPath(file.TemplateHash))
+		if !stat(g.Afs, snapshot) {
+			if err := tx.writeFile(snapshot, []byte(result.template)); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := manifest.encode()
+	if err != nil {
+		return err
+	}
+	return tx.writeFile(filepath.Join(g.Root, ManifestName), data)
+}
@@ -0,0 +1,216 @@
+package project
+
+import (
+	"bytes"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpgrade(t *testing.T) {
+	viper.Set("license", "none")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+
+	project, err := NewProject([]string{"myproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project.SetPkgName("github.com/acme/myproject")
+
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.PrepareModels(); err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.CreateProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	root := project.AbsolutePath
+	rootGo := filepath.Join(root, "cmd", "root.go")
+	mainGo := filepath.Join(root, "main.go")
+
+	// the user edits cmd/root.go, the new templates change it elsewhere
+	edit(t, fs, rootGo, "A brief description of your application", "Serves the acme API")
+
+	rootNone, err := templates.ReadFile("tpl/root_none.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mainNone, err := templates.ReadFile("tpl/main_none.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upgraded := strings.Replace(string(rootNone), "DisableDefaultCmd = true\n", "DisableDefaultCmd = true\n\trootCmd.SilenceUsage = true\n", 1)
+	if err := afero.WriteFile(fs, "/templates/root_none.tmpl", []byte(upgraded), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(fs, "/templates/main_none.tmpl", append(mainNone, "\n// upgraded\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("templates", "/templates")
+
+	results := upgradeProject(t, fs, root)
+	want := map[string]string{
+		rootGo: UpgradeMerged,
+		mainGo: UpgradeUpdated,
+	}
+	for _, result := range results {
+		status, ok := want[result.FilePath]
+		if !ok {
+			status = UpgradeUpToDate
+		}
+		if result.Status != status {
+			t.Errorf("%s: status %s, want %s", result.FilePath, result.Status, status)
+		}
+	}
+
+	data, err := afero.ReadFile(fs, rootGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("Serves the acme API")) || !bytes.Contains(data, []byte("rootCmd.SilenceUsage = true")) {
+		t.Fatalf("cmd/root.go lost a change:\n%s", data)
+	}
+
+	// the manifest now records the new templates, nothing is left to upgrade
+	for _, result := range upgradeProject(t, fs, root) {
+		if result.Status != UpgradeUpToDate {
+			t.Errorf("%s: status %s after upgrading", result.FilePath, result.Status)
+		}
+	}
+
+	// an edit clashing with the templates is left with conflict markers
+	edit(t, fs, rootGo, "rootCmd.SilenceUsage = true", "rootCmd.SilenceUsage = false")
+	upgraded = strings.Replace(upgraded, "SilenceUsage = true", "SilenceErrors = true", 1)
+	if err := afero.WriteFile(fs, "/templates/root_none.tmpl", []byte(upgraded), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, result := range upgradeProject(t, fs, root) {
+		if result.FilePath == rootGo && (result.Status != UpgradeConflict || result.Conflicts != 1) {
+			t.Errorf("cmd/root.go: status %s with %d conflict(s), want a conflict", result.Status, result.Conflicts)
+		}
+	}
+
+	data, err = afero.ReadFile(fs, rootGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(markerOurs+"\n\trootCmd.SilenceUsage = false\n")) {
+		t.Fatalf("cmd/root.go has no conflict markers:\n%s", data)
+	}
+}
+
+func TestUpgradeGroupImports(t *testing.T) {
+	viper.Set("license", "none")
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+
+	project, err := NewProject([]string{"myproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project.SetPkgName("github.com/acme/myproject")
+
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator.GroupImports = true
+	if err := generator.PrepareModels(); err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.CreateProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	root := project.AbsolutePath
+	rootGo := filepath.Join(root, "cmd", "root.go")
+
+	rootNone, err := templates.ReadFile("tpl/root_none.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	upgraded := strings.Replace(string(rootNone), "DisableDefaultCmd = true\n", "DisableDefaultCmd = true\n\trootCmd.SilenceUsage = true\n", 1)
+	if err := afero.WriteFile(fs, "/templates/root_none.tmpl", []byte(upgraded), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("templates", "/templates")
+
+	// the upgrade renders with the imports grouped as recorded, without
+	// --group-imports, so the untouched cmd/root.go is simply replaced
+	for _, result := range upgradeProject(t, fs, root) {
+		status := UpgradeUpToDate
+		if result.FilePath == rootGo {
+			status = UpgradeUpdated
+		}
+		if result.Status != status {
+			t.Errorf("%s: status %s, want %s", result.FilePath, result.Status, status)
+		}
+	}
+
+	manifest, err := LoadManifest(fs, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !manifest.GroupImports {
+		t.Error("the manifest no longer records grouped imports")
+	}
+	data, err := afero.ReadFile(fs, rootGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file, _ := manifest.File("cmd/root.go"); file.Modified(data) {
+		t.Errorf("cmd/root.go is recorded with another hash than its content:\n%s", data)
+	}
+	if !bytes.Contains(data, []byte("viper\"\n\n\t\"github.com/acme/myproject")) {
+		t.Errorf("cmd/root.go does not have its imports grouped:\n%s", data)
+	}
+}
+
+func upgradeProject(t *testing.T, fs afero.Fs, root string) []UpgradeResult {
+	t.Helper()
+
+	project, err := NewProject(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generator, err := NewProjectGenerator(fs, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := generator.PlanUpgrade(filepath.Join(root, "cmd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.Upgrade(results); err != nil {
+		t.Fatal(err)
+	}
+	return results
+}
+
+func edit(t *testing.T, fs afero.Fs, path, old, new string) {
+	t.Helper()
+
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(old)) {
+		t.Fatalf("%s does not contain %q", path, old)
+	}
+	if err := afero.WriteFile(fs, path, bytes.Replace(data, []byte(old), []byte(new), 1), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
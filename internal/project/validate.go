@@ -0,0 +1,249 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"text/template"
+	"text/template/parse"
+)
+
+// checkTemplateFields parses the template of content and reports every
+// field or method it reads that does not exist on the type of content.Data,
+// naming the template file, the Content and the position in the template.
+// Fields read through maps or interfaces can only be checked while
+// executing, where missingkey=error takes over, .Vars aside: see withVars.
+func checkTemplateFields(content Content) error {
+	if content.Data == nil {
+		return nil
+	}
+
+	tmpl, err := template.New(content.Name).Funcs(funcMap()).Parse(content.TemplateContent)
+	if err != nil {
+		return fmt.Errorf("check %s (template %s): %w", content.Name, content.TemplateFilePath, err)
+	}
+
+	root := reflect.TypeOf(content.Data)
+
+	var errs []error
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Root == nil {
+			continue
+		}
+
+		c := &fieldChecker{tree: t.Tree, root: root}
+		c.walk(t.Root, root)
+		for _, err := range c.errs {
+			errs = append(errs, fmt.Errorf("check %s (template %s): %w", content.Name, content.TemplateFilePath, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type fieldChecker struct {
+	tree *parse.Tree
+	root reflect.Type
+	errs []error
+	vars []string
+}
+
+// walk checks node with dot of type dot; a nil dot is a type that cannot be
+// known before executing.
+func (c *fieldChecker) walk(node parse.Node, dot reflect.Type) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(child, dot)
+		}
+	case *parse.ActionNode:
+		c.pipe(n.Pipe, dot)
+	case *parse.TemplateNode:
+		c.pipe(n.Pipe, dot)
+	case *parse.IfNode:
+		c.pipe(n.Pipe, dot)
+		c.walk(n.List, dot)
+		c.walk(n.ElseList, dot)
+	case *parse.WithNode:
+		inner := c.pipe(n.Pipe, dot)
+		c.walk(n.List, inner)
+		c.walk(n.ElseList, dot)
+	case *parse.RangeNode:
+		inner := elemType(c.pipe(n.Pipe, dot))
+		c.walk(n.List, inner)
+		c.walk(n.ElseList, dot)
+	}
+}
+
+// pipe checks every command of pipe and returns the type it yields when it
+// is a plain field access.
+func (c *fieldChecker) pipe(pipe *parse.PipeNode, dot reflect.Type) reflect.Type {
+	if pipe == nil {
+		return nil
+	}
+
+	var result reflect.Type
+	for _, cmd := range pipe.Cmds {
+		result = nil
+		for _, arg := range cmd.Args {
+			t := c.arg(arg, dot)
+			if len(cmd.Args) == 1 {
+				result = t
+			}
+		}
+	}
+	return result
+}
+
+func (c *fieldChecker) arg(arg parse.Node, dot reflect.Type) reflect.Type {
+	switch a := arg.(type) {
+	case *parse.FieldNode:
+		return c.resolve(a, dot, a.Ident)
+	case *parse.VariableNode:
+		if a.Ident[0] == "$" {
+			return c.resolve(a, c.root, a.Ident[1:])
+		}
+	case *parse.DotNode:
+		return dot
+	case *parse.PipeNode:
+		return c.pipe(a, dot)
+	case *parse.ChainNode:
+		if inner, ok := a.Node.(*parse.PipeNode); ok {
+			return c.resolve(a, c.pipe(inner, dot), a.Field)
+		}
+	}
+	return nil
+}
+
+// resolve follows fields from t, recording an error for the first one that
+// does not exist. It returns the type of the last field, or nil when it
+// cannot be known.
+func (c *fieldChecker) resolve(node parse.Node, t reflect.Type, fields []string) reflect.Type {
+	for i, field := range fields {
+		if t == nil {
+			return nil
+		}
+		if i > 0 && fields[i-1] == "Vars" && t.Kind() == reflect.Map {
+			c.vars = append(c.vars, field)
+		}
+
+		next, known, ok := fieldType(t, field)
+		if !ok {
+			location, _ := c.tree.ErrorContext(node)
+			c.errs = append(c.errs, fmt.Errorf("%s: %s has no field or method %s", location, t, field))
+			return nil
+		}
+		if !known {
+			return nil
+		}
+		t = next
+	}
+	return t
+}
+
+// fieldType returns the type of field on t. known is false when t is a map
+// or an interface, whose content is only known while executing.
+func fieldType(t reflect.Type, field string) (next reflect.Type, known, ok bool) {
+	if method, found := t.MethodByName(field); found {
+		return methodResult(method), true, true
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Interface {
+		if method, found := reflect.PointerTo(t).MethodByName(field); found {
+			return methodResult(method), true, true
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if f, found := t.FieldByName(field); found && f.IsExported() {
+			return f.Type, true, true
+		}
+		return nil, false, false
+	case reflect.Map, reflect.Interface:
+		return nil, false, true
+	default:
+		return nil, false, false
+	}
+}
+
+func methodResult(method reflect.Method) reflect.Type {
+	if method.Type.NumOut() == 0 {
+		return nil
+	}
+	return method.Type.Out(0)
+}
+
+// elemType returns the type range yields over values of t.
+func elemType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		return t.Elem()
+	default:
+		return nil
+	}
+}
+
+// referencedVars returns the names of the .Vars entries tmpl reads, as in
+// {{ .Vars.Port }}, when executed with data.
+func referencedVars(tmpl *template.Template, data any) []string {
+	var vars []string
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Root == nil {
+			continue
+		}
+		c := &fieldChecker{tree: t.Tree, root: reflect.TypeOf(data)}
+		c.walk(t.Root, c.root)
+		vars = append(vars, c.vars...)
+	}
+	return vars
+}
+
+// withVars returns data with the .Vars entries named vars that are not set
+// added as empty strings, so that a template reading a variable that is not
+// set renders it empty, for default to replace, rather than failing with
+// missingkey=error. data is copied, never changed.
+func withVars(data any, vars []string) any {
+	var project *Project
+	switch d := data.(type) {
+	case *Project:
+		project = d
+	case Command:
+		project = d.Project
+	}
+	if project == nil || !slices.ContainsFunc(vars, func(name string) bool {
+		_, ok := project.Vars[name]
+		return !ok
+	}) {
+		return data
+	}
+
+	copied := *project
+	copied.Vars = make(map[string]string, len(project.Vars)+len(vars))
+	maps.Copy(copied.Vars, project.Vars)
+	for _, name := range vars {
+		if _, ok := copied.Vars[name]; !ok {
+			copied.Vars[name] = ""
+		}
+	}
+
+	if command, ok := data.(Command); ok {
+		command.Project = &copied
+		return command
+	}
+	return &copied
+}
@@ -0,0 +1,127 @@
+package project
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"testing"
+)
+
+// templateData returns a value of the Content.Data type an embedded
+// template is rendered with.
+func templateData(name string) any {
+	switch {
+	case strings.HasPrefix(name, "license_"):
+		return &License{}
+	case strings.HasPrefix(name, "add_command"):
+		return Command{Project: &Project{}}
+	default:
+		return &Project{}
+	}
+}
+
+func TestEmbeddedTemplateFields(t *testing.T) {
+	names, err := fs.Glob(templates, "tpl/*.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range names {
+		t.Run(path.Base(name), func(t *testing.T) {
+			data, err := templates.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			content := Content{
+				Name:             strings.TrimSuffix(path.Base(name), ".tmpl"),
+				TemplateFilePath: name,
+				TemplateContent:  string(data),
+				Data:             templateData(path.Base(name)),
+			}
+			if err := checkTemplateFields(content); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCheckTemplateFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		data     any
+		want     string
+	}{
+		{name: "valid", template: "{{ .CmdName }} {{ .AppName }} {{ .Legal.Copyright }} {{ .Vars.Anything }}", data: Command{}},
+		{name: "method", template: "{{ .SetAppName }}", data: &Project{}},
+		{name: "range", template: "{{ range .Args }}{{ . }}{{ end }}{{ with .Legal }}{{ .Header }}{{ end }}", data: &Project{}},
+		{name: "root variable", template: "{{ with .Legal }}{{ $.AppName }}{{ end }}", data: &Project{}},
+		{name: "typo", template: "{{ .CmdNme }}", data: Command{}, want: "add_command:1:3: project.Command has no field or method CmdNme"},
+		{name: "nested typo", template: "\n{{ .Legal.Copyrigth }}", data: &Project{}, want: "add_command:2:9: *project.License has no field or method Copyrigth"},
+		{name: "inside with", template: "{{ with .Legal }}{{ .AppName }}{{ end }}", data: &Project{}, want: "has no field or method AppName"},
+		{name: "function argument", template: "{{ kebab .CmdNam }}", data: Command{}, want: "has no field or method CmdNam"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTemplateFields(Content{
+				Name:             "add_command",
+				TemplateFilePath: "tpl/add_command.tmpl",
+				TemplateContent:  tt.template,
+				Data:             tt.data,
+			})
+
+			if tt.want == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "tpl/add_command.tmpl") {
+				t.Fatalf("expected an error naming the template and %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRenderUnsetVars(t *testing.T) {
+	vars := map[string]string{"Name": "api"}
+	tests := []struct {
+		name     string
+		template string
+		data     any
+		want     string
+		err      string
+	}{
+		{name: "default", template: `{{ default "8080" .Vars.Port }}`, data: &Project{Vars: vars}, want: "8080"},
+		{name: "set", template: `{{ default "8080" .Vars.Name }}`, data: &Project{Vars: vars}, want: "api"},
+		{name: "no vars", template: `[{{ .Vars.Port }}]`, data: &Project{}, want: "[]"},
+		{name: "command", template: `{{ default "8080" .Vars.Port }}`, data: Command{Project: &Project{Vars: vars}}, want: "8080"},
+		{name: "typo", template: `{{ .AppNme }}`, data: &Project{}, err: "can't evaluate field AppNme"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderContent(Content{Name: tt.name, TemplateContent: tt.template, Data: tt.data})
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// the data of the content is left as is
+	if _, ok := vars["Port"]; ok {
+		t.Error("rendering added Port to the vars of the project")
+	}
+}
@@ -0,0 +1,22 @@
+package project
+
+import (
+	"runtime/debug"
+)
+
+// version is the version of cobra-cli, set at build time with
+// -ldflags "-X github.com/inovacc/cobra-cli/internal/project.version=v1.2.3".
+var version = ""
+
+// Version returns the version of cobra-cli, falling back to the module
+// version recorded by `go install` and to "dev" for local builds.
+func Version() string {
+	if version != "" {
+		return version
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}